@@ -49,6 +49,13 @@ func (server *Server) cretaeDriver(c *gin.Context) {
 		return
 	}
 
+	// * The old token still carries the passenger role
+	resp.Token, err = server.createAccessToken(c, result.Email, result.Phone, result.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	c.JSON(http.StatusOK, resp)
 
 }
@@ -1,9 +1,18 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/achintya-7/car_pooling_backend/mapsApi"
+	"github.com/gin-gonic/gin"
+)
 
 func (server *Server) entryPoint(c *gin.Context) {
+	maps := "ok"
+	if reporter, ok := server.maps.(mapsApi.HealthReporter); ok && reporter.Degraded() {
+		maps = "degraded"
+	}
+
 	c.JSON(200, gin.H{
 		"message": "API is running",
+		"maps":    maps,
 	})
 }
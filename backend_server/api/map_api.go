@@ -1,13 +1,69 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/achintya-7/car_pooling_backend/mapsApi"
 	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
 	"github.com/gin-gonic/gin"
 )
 
+const defaultPredictionsBiasRadius = 20000 // meters
+
+var errMapsUnavailable = errors.New("maps service is unavailable, please try again later")
+
+// mapsContext cancels the maps calls with the request. Admins can skip the
+// maps cache with a Cache-Control: no-cache header.
+func mapsContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+
+	payload, ok := c.Get(authorizationPayloadKey)
+	if !ok || payload.(*token.Payload).Role != token.RoleAdmin {
+		return ctx
+	}
+	if strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+		return mapsApi.WithoutCache(ctx)
+	}
+
+	return ctx
+}
+
+// mapsErrorStatus is the status to answer when the maps provider failed.
+func mapsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, mapsApi.ErrInvalidPlace):
+		return http.StatusBadRequest
+	case errors.Is(err, mapsApi.ErrNoRoute):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, mapsApi.ErrQuotaExceeded), errors.Is(err, mapsApi.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, mapsApi.ErrDenied):
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mapsErrorResponse only tells clients about their own mistakes, the other
+// failures are logged as they may carry details of the maps api.
+func mapsErrorResponse(err error) gin.H {
+	for _, clientErr := range []error{mapsApi.ErrInvalidPlace, mapsApi.ErrNoRoute} {
+		if errors.Is(err, clientErr) {
+			return errorResponse(clientErr)
+		}
+	}
+
+	log.Printf("maps api call failed : %v", err)
+	return errorResponse(errMapsUnavailable)
+}
+
 func (server *Server) placePredicions(c *gin.Context) {
 	var req models.GetPlaceStringReq
 
@@ -16,9 +72,26 @@ func (server *Server) placePredicions(c *gin.Context) {
 		return
 	}
 
-	prediction, err := mapsApi.GetPlacePredictions(req.Place, server.config)
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	options := server.predictionOptions
+	options.SessionToken = req.SessionToken
+	if req.Lat != nil {
+		options.Bias = mapsApi.LocationBias{
+			Center: &mapsApi.Point{Lat: *req.Lat, Lng: *req.Lng},
+			Radius: req.Radius,
+		}
+		if options.Bias.Radius == 0 {
+			options.Bias.Radius = defaultPredictionsBiasRadius
+		}
+	}
+
+	prediction, err := server.maps.PlacePredictions(mapsContext(c), req.Place, options)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
 		return
 	}
 
@@ -34,12 +107,22 @@ func (server *Server) placeRoute(c *gin.Context) {
 		return
 	}
 
-	route, err := mapsApi.GetRoute(req.Origin, req.Destination, server.config)
+	route, err := server.maps.Route(mapsContext(c), req.Origin, req.Destination)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, route)
 
 }
+
+func (server *Server) mapsCacheStats(c *gin.Context) {
+	cache, ok := server.maps.(*mapsApi.Cache)
+	if !ok {
+		c.JSON(http.StatusNotFound, errorResponse(errors.New("maps cache is disabled")))
+		return
+	}
+
+	c.JSON(http.StatusOK, cache.Stats())
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/mapsApi"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newMapsTestServer() *Server {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{maps: mapsApi.NewFakeProvider()}
+
+	r := gin.New()
+	r.GET("/api/placePredictions/:place", server.placePredicions)
+	r.POST("/api/route", server.placeRoute)
+	server.router = r
+
+	return server
+}
+
+func TestPlacePredictions(t *testing.T) {
+	server := newMapsTestServer()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/placePredictions/metro", nil)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var predictions []mapsApi.Predictions
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &predictions))
+	require.Equal(t, []mapsApi.Predictions{
+		{Description: "Botanical Garden Metro Station, Sector 38, Noida", PlaceId: "fake-botanical-garden"},
+	}, predictions)
+}
+
+func TestPlaceRoute(t *testing.T) {
+	server := newMapsTestServer()
+
+	body, err := json.Marshal(gin.H{"origin": "fake-amity-university", "destination": "fake-botanical-garden"})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/route", bytes.NewReader(body))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var route mapsApi.Route
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &route))
+	require.NotEmpty(t, route.Path)
+	require.Equal(t, 28.5641, route.Bounds.LatNE)
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodPost, "/api/route", bytes.NewReader([]byte(`{}`)))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestMapsErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusBadRequest, mapsErrorStatus(fmt.Errorf("cannot get route : %w", mapsApi.ErrInvalidPlace)))
+	require.Equal(t, http.StatusUnprocessableEntity, mapsErrorStatus(mapsApi.ErrNoRoute))
+	require.Equal(t, http.StatusServiceUnavailable, mapsErrorStatus(mapsApi.ErrQuotaExceeded))
+	require.Equal(t, http.StatusBadGateway, mapsErrorStatus(mapsApi.ErrDenied))
+	require.Equal(t, http.StatusInternalServerError, mapsErrorStatus(errors.New("boom")))
+}
+
+func TestMapsErrorResponse(t *testing.T) {
+	require.Equal(t, errorResponse(mapsApi.ErrNoRoute), mapsErrorResponse(fmt.Errorf("cannot get route : %w", mapsApi.ErrNoRoute)))
+	require.Equal(t, errorResponse(errMapsUnavailable), mapsErrorResponse(errors.New(`Get "https://maps.googleapis.com/maps/api/geocode/json?key=secret": timeout`)))
+}
+
+func TestPlaceRouteUnknownPlace(t *testing.T) {
+	server := newMapsTestServer()
+
+	body, err := json.Marshal(gin.H{"origin": "fake-amity-university", "destination": "nowhere"})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/route", bytes.NewReader(body))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestMapsCacheBypass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provider := mapsApi.NewFakeProvider()
+	server := &Server{maps: mapsApi.NewCache(provider, mapsApi.CacheConfig{})}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(authorizationPayloadKey, &token.Payload{Role: token.Role(c.GetHeader("X-Role"))})
+	})
+	r.GET("/api/placePredictions/:place", server.placePredicions)
+	r.GET("/api/cache/stats", server.mapsCacheStats)
+
+	predict := func(role token.Role, cacheControl string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/api/placePredictions/noida", nil)
+		request.Header.Set("X-Role", string(role))
+		request.Header.Set("Cache-Control", cacheControl)
+		r.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	predict(token.RolePassenger, "")
+	predict(token.RolePassenger, "no-cache") // * only admins can skip the cache
+	require.Equal(t, 1, provider.Calls("PlacePredictions"))
+
+	predict(token.RoleAdmin, "no-cache")
+	require.Equal(t, 2, provider.Calls("PlacePredictions"))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cache/stats", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var stats map[string]mapsApi.CacheStats
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &stats))
+	require.Equal(t, mapsApi.CacheStats{Hits: 1, Misses: 1}, stats["predictions"])
+}
+
+func TestPlacePredictionsInvalidBias(t *testing.T) {
+	server := newMapsTestServer()
+
+	for _, query := range []string{"?lat=28.5", "?lat=100&lng=77.3", "?lat=28.5&lng=77.3&radius=10"} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/api/placePredictions/noida"+query, nil)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/placePredictions/noida?lat=28.5&lng=77.3&session_token=abc", nil)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestNewPredictionOptions(t *testing.T) {
+	options, err := newPredictionOptions(utils.Config{MapsBiasLat: 28.544, MapsBiasLng: 77.3331, MapsCountries: []string{"in"}})
+	require.NoError(t, err)
+	require.Equal(t, &mapsApi.Point{Lat: 28.544, Lng: 77.3331}, options.Bias.Center)
+	require.Equal(t, float64(defaultPredictionsBiasRadius), options.Bias.Radius)
+	require.Equal(t, []string{"in"}, options.Countries)
+
+	options, err = newPredictionOptions(utils.Config{MapsBiasBounds: []float64{28.4, 77.2, 28.7, 77.5}})
+	require.NoError(t, err)
+	require.Nil(t, options.Bias.Center)
+	require.Equal(t, &mapsApi.Bounds{LatSW: 28.4, LngSW: 77.2, LatNE: 28.7, LngNE: 77.5}, options.Bias.Bounds)
+
+	_, err = newPredictionOptions(utils.Config{MapsBiasBounds: []float64{28.4, 77.2}})
+	require.Error(t, err)
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/achintya-7/car_pooling_backend/token"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const (
@@ -16,7 +18,7 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, revokedTokens *mongo.Collection) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(authorizationHeaderKey)
 		if authHeader == "" {
@@ -46,7 +48,37 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// * Tokens revoked on logout stay in the blacklist until they expire
+		err = revokedTokens.FindOne(c, bson.M{"_id": claims.ID.String()}).Err()
+		if err == nil {
+			err := errors.New("token has been revoked")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
 		c.Set(authorizationPayloadKey, claims)
 		c.Next()
 	}
 }
+
+// requireRole only lets the request through when the authenticated user has
+// one of the given roles. It must run after authMiddleware.
+func requireRole(roles ...token.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if authPayload.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %q is not allowed to access this resource", authPayload.Role)
+		c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
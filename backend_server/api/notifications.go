@@ -0,0 +1,310 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/templates"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultNotificationsLimit = 20
+	notificationBufferSize    = 32
+	streamHeartbeatInterval   = 25 * time.Second
+	// * How long a notification can take to commit after its id is generated
+	streamReplayWindow = time.Minute
+)
+
+// deliverNotifications pushes notifications that have been committed to the
+// database to the recipients that are currently connected, and queues them as
+// push messages for their devices.
+func (server *Server) deliverNotifications(notifications ...models.NotificationModel) {
+	server.hub.Publish(notifications...)
+	server.dispatcher.Dispatch(notifications...)
+}
+
+// notificationLanguage returns the language notifications are rendered in
+// for the given user.
+func (server *Server) notificationLanguage(c *gin.Context, email string) (string, error) {
+	var passenger models.CreatePassengerResponse
+
+	err := server.collection.Passenger.FindOne(c, bson.M{"email": email}).Decode(&passenger)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return "", err
+	}
+
+	if passenger.Language == "" {
+		return templates.DefaultLanguage, nil
+	}
+
+	return passenger.Language, nil
+}
+
+// renderNotification fills the title and content of the notification, falling
+// back to its kind so that one broken template does not hide the others.
+func (server *Server) renderNotification(language string, notification *models.NotificationModel) {
+	if err := server.templates.RenderNotification(language, notification); err != nil {
+		log.Printf("cannot render notification %s : %v", notification.ID.Hex(), err)
+		notification.Title = notification.Type.String()
+	}
+}
+
+func (server *Server) getNotifications(c *gin.Context) {
+	var req models.ListNotificationsReq
+	result := []models.NotificationModel{}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultNotificationsLimit
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"email": authPayload.Email}
+	if req.Unread {
+		filter["read"] = bson.M{"$ne": true}
+	}
+
+	// * Newest first, the cursor is the id of the last notification already seen
+	if req.Cursor != "" {
+		cursorId, err := utils.StringToObjectId(req.Cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		filter["_id"] = bson.M{"$lt": cursorId}
+	}
+
+	// * Fetch one extra document to know if there is a next page
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(req.Limit + 1)
+
+	cursor, err := server.collection.Notification.Find(c, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err = cursor.All(c, &result); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	language, err := server.notificationLanguage(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	for i := range result {
+		server.renderNotification(language, &result[i])
+	}
+
+	resp := models.ListNotificationsResp{Notifications: result}
+	if int64(len(result)) > req.Limit {
+		resp.Notifications = result[:req.Limit]
+		resp.NextCursor = resp.Notifications[req.Limit-1].ID.Hex()
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (server *Server) markNotificationRead(c *gin.Context) {
+	var req models.NotificationIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	notificationId, err := utils.StringToObjectId(req.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"_id": notificationId, "email": authPayload.Email}
+	update := bson.M{"$set": bson.M{"read": true}}
+
+	result, err := server.collection.Notification.UpdateOne(c, filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		err := errors.New("notification not found")
+		c.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (server *Server) markAllNotificationsRead(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"email": authPayload.Email, "read": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"read": true}}
+
+	result, err := server.collection.Notification.UpdateMany(c, filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notifications marked as read", "count": result.ModifiedCount})
+}
+
+func (server *Server) deleteNotification(c *gin.Context) {
+	var req models.NotificationIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	notificationId, err := utils.StringToObjectId(req.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"_id": notificationId, "email": authPayload.Email}
+
+	result, err := server.collection.Notification.DeleteOne(c, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		err := errors.New("notification not found")
+		c.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification deleted successfully"})
+}
+
+// streamNotifications sends notifications as Server-Sent Events as soon as
+// they are published. Clients resume with the Last-Event-ID header, missed
+// notifications are replayed from the database before the live ones. Ids are
+// generated before the notifications are committed, so the replay starts a
+// window before the last event and clients drop the ids they already have.
+func (server *Server) streamNotifications(c *gin.Context) {
+	var missed []models.NotificationModel
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	var lastSent primitive.ObjectID
+	if lastEventID != "" {
+		var err error
+		if lastSent, err = utils.StringToObjectId(lastEventID); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	language, err := server.notificationLanguage(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// * Subscribe before the replay so nothing published in between is lost
+	sub := server.hub.Subscribe(authPayload.Email)
+	defer server.hub.Unsubscribe(sub)
+
+	if !lastSent.IsZero() {
+		from := primitive.NewObjectIDFromTimestamp(lastSent.Timestamp().Add(-streamReplayWindow))
+		filter := bson.M{"email": authPayload.Email, "_id": bson.M{"$gt": from, "$ne": lastSent}}
+		opts := options.Find().SetSort(bson.M{"_id": 1})
+
+		cursor, err := server.collection.Notification.Find(c, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if err = cursor.All(c, &missed); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// * Ids are not published in order, only the replayed ones can come again
+	replayed := make(map[primitive.ObjectID]bool, len(missed))
+
+	send := func(notification models.NotificationModel) {
+		if replayed[notification.ID] {
+			return
+		}
+
+		server.renderNotification(language, &notification)
+
+		c.Render(-1, sse.Event{
+			Id:    notification.ID.Hex(),
+			Event: "notification",
+			Data:  notification,
+		})
+	}
+
+	for _, notification := range missed {
+		send(notification)
+		replayed[notification.ID] = true
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-server.shutdown.Done():
+			return false
+		case notification, ok := <-sub.C:
+			if !ok {
+				// * Too slow to keep up, the client reconnects with Last-Event-ID
+				return false
+			}
+			send(notification)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderNotificationFallsBackToKind(t *testing.T) {
+	registry, err := templates.DefaultRegistry()
+	require.NoError(t, err)
+
+	server := &Server{templates: registry}
+
+	notification := models.NotificationModel{Type: models.NotificationRideCancelled}
+	server.renderNotification("en", &notification)
+	require.NotEmpty(t, notification.Content)
+
+	// * A kind without a template still shows up in the inbox
+	unknown := models.NotificationModel{Type: models.NotificationKind(99)}
+	server.renderNotification("en", &unknown)
+	require.Equal(t, "unknown_99", unknown.Title)
+	require.Empty(t, unknown.Content)
+}
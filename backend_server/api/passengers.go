@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/achintya-7/car_pooling_backend/models"
 	"github.com/achintya-7/car_pooling_backend/token"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -24,7 +26,25 @@ func (server *Server) createPassenger(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(req.Email, req.Phone, req.Name, server.config.AccessTokenDuration)
+	// * The account must belong to the firebase user that signed in on the device
+	idToken, err := server.firebase.Verify(c, req.IDToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if req.FirebaseID == "" {
+		req.FirebaseID = idToken.UID
+	}
+
+	if !strings.EqualFold(req.Email, idToken.Email) || req.FirebaseID != idToken.UID {
+		err := errors.New("email and firebase id must match the id token")
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	req.Email = idToken.Email
+
+	accessToken, err := server.createAccessToken(c, req.Email, req.Phone, req.Name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -57,10 +77,66 @@ func (server *Server) createPassenger(c *gin.Context) {
 		return
 	}
 
+	resp.RefreshToken, _, err = server.createSession(c, resp.Email, uuid.NewString())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	c.JSON(http.StatusOK, resp)
 
 }
 
+func (server *Server) loginPassenger(c *gin.Context) {
+	var req models.LoginPassengerRequest
+	var result models.CreatePassengerResponse
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// * Verify the user before minting a new token
+	idToken, err := server.firebase.Verify(c, req.IDToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	filter := bson.M{"email": idToken.Email, "firebase_id": idToken.UID}
+	if err := server.collection.Passenger.FindOne(c, filter).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("no passenger registered for this firebase user")
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accessToken, err := server.createAccessToken(c, result.Email, result.Phone, result.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	update := bson.M{"$set": bson.M{"token": accessToken}}
+	options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	if err := server.collection.Passenger.FindOneAndUpdate(c, filter, update, options).Decode(&result); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result.RefreshToken, _, err = server.createSession(c, result.Email, uuid.NewString())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (server *Server) updatePassenger(c *gin.Context) {
 	var req models.UpdatePassengerRequest
 	var result models.CreatePassengerResponse
@@ -83,7 +159,7 @@ func (server *Server) updatePassenger(c *gin.Context) {
 	}
 	defer session.EndSession(c)
 
-	token, err := server.tokenMaker.CreateToken(authPayload.Email, req.Phone, req.Name, server.config.AccessTokenDuration)
+	token, err := server.createAccessToken(c, authPayload.Email, req.Phone, req.Name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -117,6 +193,15 @@ func (server *Server) updatePassenger(c *gin.Context) {
 	}
 
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var current models.CreatePassengerResponse
+
+		// * A new phone number has to be verified again
+		if err := server.collection.Passenger.FindOne(c, filter).Decode(&current); err != nil {
+			return nil, err
+		}
+		if current.Phone != req.Phone {
+			updateDoc["phone_verified"] = false
+		}
 
 		if err := server.collection.Passenger.FindOneAndUpdate(c, filter, update, options).Decode(&result); err != nil {
 			return nil, err
@@ -0,0 +1,761 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/scheduler"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+var (
+	errRecurringRideFull    = errors.New("recurring ride has no seats left")
+	errRecurringRideOverlap = errors.New("ride already exists at the time of an upcoming ride")
+)
+
+// busyInstances returns the upcoming rides of a recurring ride that depart
+// around another active ride of the passenger.
+func (server *Server) busyInstances(ctx context.Context, recurringID string, email string) ([]primitive.ObjectID, error) {
+	var instances []models.CreateRideResp
+	var rides []models.CreateRideResp
+
+	projection := options.Find().SetProjection(bson.M{"_id": 1, "timestamp": 1})
+
+	filter := bson.M{"recurring_id": recurringID, "status": models.RideScheduled}
+	cursor, err := server.collection.Ride.Find(ctx, filter, projection)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &instances); err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+
+	var departures bson.A
+	for _, instance := range instances {
+		departures = append(departures, bson.M{"timestamp": overlapFilter(instance.Timestamp)})
+	}
+
+	filter2 := bson.M{
+		"status":           statusIn(models.ActiveRideStatuses...),
+		"recurring_id":     bson.M{"$ne": recurringID},
+		"passengers.email": email,
+		"$or":              departures,
+	}
+	cursor, err = server.collection.Ride.Find(ctx, filter2, projection)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &rides); err != nil {
+		return nil, err
+	}
+
+	window := int64(models.RideOverlapWindow / time.Second)
+
+	var busy []primitive.ObjectID
+	for _, instance := range instances {
+		for _, ride := range rides {
+			if ride.Timestamp < instance.Timestamp-window || ride.Timestamp > instance.Timestamp+window {
+				continue
+			}
+			id, err := utils.StringToObjectId(instance.Id)
+			if err != nil {
+				return nil, err
+			}
+			busy = append(busy, id)
+			break
+		}
+	}
+
+	return busy, nil
+}
+
+// leaveRecurringRide pulls the subscriber out of the recurring ride matching
+// filter and out of its rides departing after the cancellation cutoff. Rides
+// departing sooner keep them, as passengerLeave would refuse to.
+func (server *Server) leaveRecurringRide(sessCtx mongo.SessionContext, filter bson.M, recurringID string, email string) (models.RecurringRide, error) {
+	var template models.RecurringRide
+
+	update := bson.M{"$pull": bson.M{"subscribers": bson.M{"email": email}}}
+	if err := server.collection.RecurringRide.FindOneAndUpdate(sessCtx, filter, update).Decode(&template); err != nil {
+		return template, err
+	}
+
+	cutoff := time.Now().Add(server.cancellationCutoff()).Unix()
+
+	filter2 := bson.M{"recurring_id": recurringID, "status": models.RideScheduled, "timestamp": bson.M{"$gt": cutoff}}
+	update2 := bson.M{"$pull": bson.M{"passengers": bson.M{"email": email}}}
+	_, err := server.collection.Ride.UpdateMany(sessCtx, filter2, update2)
+
+	return template, err
+}
+
+func (server *Server) createRecurringRide(c *gin.Context) {
+	var req models.CreateRecurringRideReq
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	verified, err := server.isPhoneVerified(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, errorResponse(errPhoneNotVerified))
+		return
+	}
+
+	template := models.RecurringRide{
+		ID:            primitive.NewObjectID(),
+		Email:         authPayload.Email,
+		Phone:         authPayload.Phone,
+		Name:          req.Name,
+		Origin:        req.Origin,
+		Destination:   req.Destination,
+		Seats:         req.Seats,
+		Price:         req.Price,
+		PlaceId:       req.PlaceId,
+		Days:          req.Days,
+		DepartureTime: req.DepartureTime,
+		Timezone:      req.Timezone,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		Subscribers:   []models.RecurringSubscriber{},
+		Active:        true,
+		CreatedAt:     time.Now().Unix(),
+	}
+
+	if err := scheduler.Validate(template); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// * The driver cannot already be on a ride when one of the first rides departs
+	overlaps, err := server.scheduler.Overlaps(c, template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if overlaps {
+		c.JSON(http.StatusConflict, errorResponse(errRecurringRideOverlap))
+		return
+	}
+
+	placeRoute, err := server.maps.Route(mapsContext(c), req.Origin, req.Destination)
+	if err != nil {
+		c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
+		return
+	}
+
+	template.GeoJSON = rideGeoJSON(placeRoute)
+	template.OriginLat = placeRoute.Points[0].Lat
+	template.OriginLng = placeRoute.Points[0].Lng
+
+	if _, err := server.collection.RecurringRide.InsertOne(c, template); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// * The scheduler picks the ride up on its next run if this fails
+	if err := server.scheduler.Materialize(c, template); err != nil {
+		log.Printf("cannot materialize recurring ride %s : %v", template.ID.Hex(), err)
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (server *Server) getRecurringRides(c *gin.Context) {
+	var result []models.RecurringRide
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"email": authPayload.Email, "active": true}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := server.collection.RecurringRide.Find(c, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err = cursor.All(c, &result); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(result) == 0 {
+		c.JSON(http.StatusNotFound, []models.RecurringRide{})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// deleteRecurringRide stops a recurring ride and cancels its upcoming rides.
+func (server *Server) deleteRecurringRide(c *gin.Context) {
+	var req models.RecurringRideIDReq
+	var template models.RecurringRide
+	var notifications []models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"_id": objectId, "email": authPayload.Email, "active": true}
+		update := bson.M{"$set": bson.M{"active": false}}
+		if err := server.collection.RecurringRide.FindOneAndUpdate(sessCtx, filter, update).Decode(&template); err != nil {
+			return nil, err
+		}
+
+		// * Rides that already started are left alone
+		filter2 := bson.M{"recurring_id": req.RecurringID, "status": models.RideScheduled}
+		cursor, err := server.collection.Ride.Find(sessCtx, filter2, options.Find().SetProjection(bson.M{"_id": 1, "passengers.email": 1}))
+		if err != nil {
+			return nil, err
+		}
+
+		var rides []models.CreateRideResp
+		if err := cursor.All(sessCtx, &rides); err != nil {
+			return nil, err
+		}
+
+		// * Not nil, a recurring ride may have no upcoming rides and $in needs an array
+		rideIDs := []primitive.ObjectID{}
+		for _, ride := range rides {
+			if id, err := utils.StringToObjectId(ride.Id); err == nil {
+				rideIDs = append(rideIDs, id)
+			}
+		}
+
+		t := time.Now().Unix()
+
+		update2 := bson.M{"$set": bson.M{
+			"status":        models.RideCancelled,
+			"cancelled_at":  t,
+			"cancel_reason": scheduler.EndedReason,
+		}}
+		if _, err := server.collection.Ride.UpdateMany(sessCtx, bson.M{"_id": bson.M{"$in": rideIDs}}, update2); err != nil {
+			return nil, err
+		}
+
+		filter3 := bson.M{"ride_id": bson.M{"$in": rideIDs}, "status": bson.M{"$in": []models.RequestStatus{models.RequestPending, models.RequestAccepted}}}
+		update3 := bson.M{"$set": bson.M{"status": models.RequestCancelled}}
+		if _, err := server.collection.Request.UpdateMany(sessCtx, filter3, update3); err != nil {
+			return nil, err
+		}
+
+		// * Let the subscribers and the passengers of the rides know once instead of once per ride
+		var emails []string
+		notified := map[string]bool{template.Email: true}
+		for _, subscriber := range template.Subscribers {
+			if !notified[subscriber.Email] {
+				notified[subscriber.Email] = true
+				emails = append(emails, subscriber.Email)
+			}
+		}
+		for _, ride := range rides {
+			for _, passenger := range ride.Passengers {
+				if !notified[passenger.Email] {
+					notified[passenger.Email] = true
+					emails = append(emails, passenger.Email)
+				}
+			}
+		}
+
+		notifications = nil
+		var docs []interface{}
+		for _, email := range emails {
+			notification := models.NotificationModel{
+				ID:          primitive.NewObjectID(),
+				Email:       email,
+				SenderPhone: authPayload.Phone,
+				SenderName:  authPayload.Name,
+				DriverName:  authPayload.Name,
+				RecurringID: req.RecurringID,
+				Timestamp:   t,
+				Type:        models.NotificationRecurringRideEnded,
+			}
+			notifications = append(notifications, notification)
+			docs = append(docs, notification)
+		}
+
+		if len(docs) > 0 {
+			if _, err := server.collection.Notification.InsertMany(sessCtx, docs); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notifications...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "recurring ride deleted successfully"})
+}
+
+// subscribeRecurringRide asks the driver to ride along on every occurrence.
+func (server *Server) subscribeRecurringRide(c *gin.Context) {
+	var uri models.RecurringRideIDReq
+	var req models.SubscribeRecurringRideReq
+	var template models.RecurringRide
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(uri.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	verified, err := server.isPhoneVerified(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, errorResponse(errPhoneNotVerified))
+		return
+	}
+
+	// * Every upcoming ride has to be free, like createRequest checks for a single ride
+	busy, err := server.busyInstances(c, uri.RecurringID, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(busy) > 0 {
+		c.JSON(http.StatusConflict, errorResponse(errRecurringRideOverlap))
+		return
+	}
+
+	subscriber := models.RecurringSubscriber{
+		Email:     authPayload.Email,
+		Phone:     authPayload.Phone,
+		Name:      authPayload.Name,
+		Origin:    req.Origin,
+		OriginLat: req.OriginLat,
+		OriginLng: req.OriginLng,
+		Status:    models.RequestPending,
+	}
+
+	// * The driver cannot subscribe to their own ride and nobody subscribes twice
+	filter := bson.M{
+		"_id":               objectId,
+		"active":            true,
+		"email":             bson.M{"$ne": authPayload.Email},
+		"subscribers.email": bson.M{"$ne": authPayload.Email},
+	}
+	update := bson.M{"$push": bson.M{"subscribers": subscriber}}
+
+	err = server.collection.RecurringRide.FindOneAndUpdate(c, filter, update).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	notification := models.NotificationModel{
+		ID:          primitive.NewObjectID(),
+		Email:       template.Email,
+		SenderPhone: authPayload.Phone,
+		SenderName:  authPayload.Name,
+		RecurringID: uri.RecurringID,
+		Timestamp:   time.Now().Unix(),
+		Type:        models.NotificationSubscriptionRequested,
+	}
+
+	if _, err := server.collection.Notification.InsertOne(c, notification); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, subscriber)
+}
+
+// unsubscribeRecurringRide stops riding along, including on rides already
+// created that depart after the cancellation cutoff.
+func (server *Server) unsubscribeRecurringRide(c *gin.Context) {
+	var req models.RecurringRideIDReq
+	var notification models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{"_id": objectId, "subscribers.email": authPayload.Email}
+
+		template, err := server.leaveRecurringRide(sessCtx, filter, req.RecurringID, authPayload.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       template.Email,
+			SenderPhone: authPayload.Phone,
+			SenderName:  authPayload.Name,
+			RecurringID: req.RecurringID,
+			Timestamp:   time.Now().Unix(),
+			Type:        models.NotificationSubscriptionCancelled,
+		}
+		_, err = server.collection.Notification.InsertOne(sessCtx, notification)
+		return nil, err
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "unsubscribed from recurring ride"})
+}
+
+// acceptRecurringSubscriber adds the subscriber to the upcoming rides that
+// still have a seat, rides created later include them as well.
+func (server *Server) acceptRecurringSubscriber(c *gin.Context) {
+	var req models.RecurringSubscriberReq
+	var template models.RecurringRide
+	var notification models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{
+			"_id":    objectId,
+			"email":  authPayload.Email,
+			"active": true,
+			"subscribers": bson.M{
+				"$elemMatch": bson.M{"email": req.Email, "status": models.RequestPending},
+			},
+		}
+		if err := server.collection.RecurringRide.FindOne(sessCtx, filter).Decode(&template); err != nil {
+			return nil, err
+		}
+
+		// * The driver takes one of the seats
+		accepted := 1
+		var subscriber models.RecurringSubscriber
+		for _, s := range template.Subscribers {
+			if s.Status == models.RequestAccepted {
+				accepted++
+			}
+			if s.Email == req.Email {
+				subscriber = s
+			}
+		}
+		if accepted >= template.Seats {
+			return nil, errRecurringRideFull
+		}
+
+		update := bson.M{"$set": bson.M{"subscribers.$.status": models.RequestAccepted}}
+		if _, err := server.collection.RecurringRide.UpdateOne(sessCtx, filter, update); err != nil {
+			return nil, err
+		}
+
+		filter2 := bson.M{
+			"recurring_id":     req.RecurringID,
+			"status":           models.RideScheduled,
+			"passengers.email": bson.M{"$ne": req.Email},
+			"$expr": bson.M{
+				"$gt": bson.A{"$seats", bson.M{"$size": "$passengers"}},
+			},
+		}
+
+		// * Rides departing around another ride of the subscriber go without them
+		busy, err := server.busyInstances(sessCtx, req.RecurringID, req.Email)
+		if err != nil {
+			return nil, err
+		}
+		if len(busy) > 0 {
+			filter2["_id"] = bson.M{"$nin": busy}
+		}
+		update2 := bson.M{"$push": bson.M{"passengers": models.Passenger{
+			Email:     subscriber.Email,
+			Phone:     subscriber.Phone,
+			Name:      subscriber.Name,
+			Origin:    subscriber.Origin,
+			OriginLat: subscriber.OriginLat,
+			OriginLng: subscriber.OriginLng,
+		}}}
+		if _, err := server.collection.Ride.UpdateMany(sessCtx, filter2, update2); err != nil {
+			return nil, err
+		}
+
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       req.Email,
+			SenderPhone: authPayload.Phone,
+			SenderName:  authPayload.Name,
+			DriverName:  authPayload.Name,
+			RecurringID: req.RecurringID,
+			Timestamp:   time.Now().Unix(),
+			Type:        models.NotificationSubscriptionAccepted,
+		}
+		if _, err := server.collection.Notification.InsertOne(sessCtx, notification); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errRecurringRideFull {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "subscriber accepted"})
+}
+
+// rejectRecurringSubscriber turns down a pending subscriber, who can ask again later.
+func (server *Server) rejectRecurringSubscriber(c *gin.Context) {
+	var req models.RecurringSubscriberReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{
+		"_id":   objectId,
+		"email": authPayload.Email,
+		"subscribers": bson.M{
+			"$elemMatch": bson.M{"email": req.Email, "status": models.RequestPending},
+		},
+	}
+	update := bson.M{"$pull": bson.M{"subscribers": bson.M{"email": req.Email, "status": models.RequestPending}}}
+
+	if err := server.collection.RecurringRide.FindOneAndUpdate(c, filter, update).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	notification := models.NotificationModel{
+		ID:          primitive.NewObjectID(),
+		Email:       req.Email,
+		SenderPhone: authPayload.Phone,
+		SenderName:  authPayload.Name,
+		DriverName:  authPayload.Name,
+		RecurringID: req.RecurringID,
+		Timestamp:   time.Now().Unix(),
+		Type:        models.NotificationSubscriptionRejected,
+	}
+
+	if _, err := server.collection.Notification.InsertOne(c, notification); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "subscriber rejected"})
+}
+
+// removeRecurringSubscriber lets the driver drop an accepted subscriber from
+// the recurring ride and from its rides departing after the cancellation cutoff.
+func (server *Server) removeRecurringSubscriber(c *gin.Context) {
+	var req models.RecurringSubscriberReq
+	var notification models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RecurringID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{
+			"_id":   objectId,
+			"email": authPayload.Email,
+			"subscribers": bson.M{
+				"$elemMatch": bson.M{"email": req.Email, "status": models.RequestAccepted},
+			},
+		}
+
+		if _, err := server.leaveRecurringRide(sessCtx, filter, req.RecurringID, req.Email); err != nil {
+			return nil, err
+		}
+
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       req.Email,
+			SenderPhone: authPayload.Phone,
+			SenderName:  authPayload.Name,
+			DriverName:  authPayload.Name,
+			RecurringID: req.RecurringID,
+			Timestamp:   time.Now().Unix(),
+			Type:        models.NotificationSubscriptionRemoved,
+		}
+		_, err := server.collection.Notification.InsertOne(sessCtx, notification)
+		return nil, err
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "subscriber removed"})
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -30,63 +29,57 @@ func (server *Server) createRequest(c *gin.Context) {
 	rc := readconcern.Snapshot()
 	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
 
-	errChan1 := make(chan error)
-	errChan2 := make(chan error)
-
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	verified, err := server.isPhoneVerified(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, errorResponse(errPhoneNotVerified))
+		return
+	}
+
 	objectId, err := utils.StringToObjectId(req.RideId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// * check if user has already requested a ride
-	go func() {
-		filter := bson.M{
-			"complete":         false,
-			"passengers.email": authPayload.Email,
-		}
-
-		err = server.collection.Ride.FindOne(c, filter).Decode(&rideResult)
-
-		errChan1 <- err
-	}()
-
 	// * check if ride exists and has seats available
-	go func() {
-		filter2 := bson.M{
-			"_id":      objectId,
-			"complete": false,
-			"$expr": bson.M{
-				"$gt": bson.A{"$seats", bson.M{"$size": "$passengers"}},
-			},
-		}
-
-		err = server.collection.Ride.FindOne(c, filter2).Decode(&result)
-
-		errChan2 <- err
-	}()
-
-	err1 := <-errChan1
-	err2 := <-errChan2
+	filter2 := bson.M{
+		"_id":    objectId,
+		"status": models.RideScheduled,
+		"$expr": bson.M{
+			"$gt": bson.A{"$seats", bson.M{"$size": "$passengers"}},
+		},
+	}
 
-	if err1 == nil || rideResult.Email != "" {
-		err := errors.New("ride already exists")
+	err = server.collection.Ride.FindOne(c, filter2).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusConflict, errorResponse(err))
 		return
 	}
 
-	if err2 != nil {
-		if err2 == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, errorResponse(err2))
-			return
-		}
-		c.JSON(http.StatusConflict, errorResponse(err2))
+	// * check if user is already in a ride around the same time
+	filter := bson.M{
+		"status":           statusIn(models.ActiveRideStatuses...),
+		"passengers.email": authPayload.Email,
+		"timestamp":        overlapFilter(result.Timestamp),
+	}
+
+	err = server.collection.Ride.FindOne(c, filter).Decode(&rideResult)
+	if err == nil || rideResult.Email != "" {
+		c.JSON(http.StatusConflict, errorResponse(errRideOverlap))
 		return
 	}
 
@@ -115,7 +108,7 @@ func (server *Server) createRequest(c *gin.Context) {
 			OriginLat: req.OriginLat,
 			OriginLng: req.OriginLng,
 			Timestamp: time.Now().Unix(),
-			Status:    0,
+			Status:    models.RequestPending,
 		}
 
 		_, err = server.collection.Request.InsertOne(c, resp)
@@ -124,14 +117,14 @@ func (server *Server) createRequest(c *gin.Context) {
 		}
 
 		// * Send notification to driver
-		msg := fmt.Sprintf("New request from %s has been made for the Ride %s", authPayload.Name, req.RideId)
-
 		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
 			Email:       result.Email,
 			SenderPhone: authPayload.Phone,
 			SenderName:  authPayload.Name,
-			Type:        0,
-			Content:     msg,
+			Type:        models.NotificationRideRequested,
+			RideID:      req.RideId,
+			RequestID:   resp.ID.Hex(),
 			Timestamp:   time.Now().Unix(),
 		}
 
@@ -150,12 +143,15 @@ func (server *Server) createRequest(c *gin.Context) {
 		return
 	}
 
+	server.deliverNotifications(notification)
+
 	c.JSON(http.StatusOK, resp)
 
 }
 
 func (server *Server) getRideRequestsDriver(c *gin.Context) {
 	var req models.DriverRidesReq
+	var ride models.CreateRideResp
 	var result []models.RequestToDriverRes
 
 	if err := c.ShouldBindUri(&req); err != nil {
@@ -170,6 +166,24 @@ func (server *Server) getRideRequestsDriver(c *gin.Context) {
 		return
 	}
 
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// * Only the driver who owns the ride can see its requests
+	if err := server.collection.Ride.FindOne(c, bson.M{"_id": ride_id}).Decode(&ride); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if ride.Email != authPayload.Email && authPayload.Role != token.RoleAdmin {
+		err := errors.New("ride does not belong to the authenticated user")
+		c.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
 	filter := bson.M{"ride_id": ride_id}
 
 	cursor, err := server.collection.Request.Find(c, filter)
@@ -218,6 +232,7 @@ func (server *Server) getRideRequestsPassenger(c *gin.Context) {
 
 func (server *Server) acceptRideRequest(c *gin.Context) {
 	var req models.Passenger
+	var notification models.NotificationModel
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
@@ -229,6 +244,7 @@ func (server *Server) acceptRideRequest(c *gin.Context) {
 	session, err := server.client.StartSession()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 	defer session.EndSession(c)
 
@@ -238,54 +254,93 @@ func (server *Server) acceptRideRequest(c *gin.Context) {
 
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
 
-		// * Check if ride exists and has seats available and then push in the passenger data
+		// * Update the status of the request to accepted, the request must be a pending one made by this passenger
+		request_id, err := utils.StringToObjectId(req.RequestID)
+		if err != nil {
+			return nil, err
+		}
+
+		var request models.RequestToDriverRes
+		filter2 := bson.M{"_id": request_id, "email": req.Email, "status": models.RequestPending}
+		update2 := bson.M{"$set": bson.M{"status": models.RequestAccepted}}
+
+		if err := server.collection.Request.FindOneAndUpdate(sessCtx, filter2, update2).Decode(&request); err != nil {
+			return nil, err
+		}
+
+		// * Check if the requested ride belongs to the driver and has seats available and then push in the passenger data
 		filter := bson.M{
-			"email":    authPayload.Email,
-			"complete": false,
+			"_id":    request.RideId,
+			"email":  authPayload.Email,
+			"status": statusIn(models.RideScheduled, models.RideBoarding),
 			"$expr": bson.M{
 				"$gt": bson.A{"$seats", bson.M{"$size": "$passengers"}},
 			}}
 		update := bson.M{"$push": bson.M{"passengers": req}}
-		options := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-		result := server.collection.Ride.FindOneAndUpdate(c, filter, update, options)
-		if result.Err() != nil {
-			return nil, result.Err()
+		var ride models.CreateRideResp
+		if err := server.collection.Ride.FindOneAndUpdate(sessCtx, filter, update, opts).Decode(&ride); err != nil {
+			return nil, err
 		}
 
-		// * Update the status of the request to accepted
-		request_id, err := utils.StringToObjectId(req.RequestID)
+		// * Delete the other pending requests of the passenger for rides around the same time, the accepted one is kept to record leaving the ride
+		filter3 := bson.M{"_id": bson.M{"$ne": request_id}, "email": req.Email, "status": models.RequestPending}
+		cursor, err := server.collection.Request.Find(sessCtx, filter3, options.Find().SetProjection(bson.M{"ride_id": 1}))
 		if err != nil {
 			return nil, err
 		}
 
-		filter2 := bson.M{"_id": request_id}
-		update2 := bson.M{"$set": bson.M{"status": 1}}
-
-		updateResult := server.collection.Request.FindOneAndUpdate(c, filter2, update2)
-		if updateResult.Err() != nil {
-			return nil, updateResult.Err()
+		var pending []models.RequestToDriverRes
+		if err := cursor.All(sessCtx, &pending); err != nil {
+			return nil, err
 		}
 
-		// * Delete all other requests for the passenger
-		filter3 := bson.M{"email": req.Email}
-		_, err = server.collection.Request.DeleteMany(c, filter3)
-		if err != nil {
-			return nil, err
+		if len(pending) > 0 {
+			var pendingRideIDs []primitive.ObjectID
+			for _, other := range pending {
+				pendingRideIDs = append(pendingRideIDs, other.RideId)
+			}
+
+			filter4 := bson.M{"_id": bson.M{"$in": pendingRideIDs}, "timestamp": overlapFilter(ride.Timestamp)}
+			cursor, err := server.collection.Ride.Find(sessCtx, filter4, options.Find().SetProjection(bson.M{"_id": 1}))
+			if err != nil {
+				return nil, err
+			}
+
+			var overlapping []models.CreateRideResp
+			if err := cursor.All(sessCtx, &overlapping); err != nil {
+				return nil, err
+			}
+
+			var rideIDs []primitive.ObjectID
+			for _, other := range overlapping {
+				if id, err := utils.StringToObjectId(other.Id); err == nil {
+					rideIDs = append(rideIDs, id)
+				}
+			}
+
+			if len(rideIDs) > 0 {
+				filter3["ride_id"] = bson.M{"$in": rideIDs}
+				if _, err := server.collection.Request.DeleteMany(sessCtx, filter3); err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		// * Send notification to the passenger that the request has been accepted
-		msg := fmt.Sprintf("Your request for ride has been ACCEPTED by the driver %s", authPayload.Name)
-
-		notification := models.NotificationModel{
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
 			Email:       req.Email,
-			Content:     msg,
 			Timestamp:   time.Now().Unix(),
 			SenderPhone: authPayload.Phone,
 			SenderName:  authPayload.Name,
-			Type:        1,
+			DriverName:  authPayload.Name,
+			RideID:      ride.Id,
+			RequestID:   req.RequestID,
+			Type:        models.NotificationRequestAccepted,
 		}
-		_, err = server.collection.Notification.InsertOne(c, notification)
+		_, err = server.collection.Notification.InsertOne(sessCtx, notification)
 		if err != nil {
 			return nil, err
 		}
@@ -304,12 +359,15 @@ func (server *Server) acceptRideRequest(c *gin.Context) {
 		return
 	}
 
+	server.deliverNotifications(notification)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Request accepted successfully"})
 
 }
 
 func (server *Server) rejectRideRequest(c *gin.Context) {
 	var req models.ModifyRideRequestReq
+	var notification models.NotificationModel
 
 	wc := writeconcern.New(writeconcern.WMajority())
 	rc := readconcern.Linearizable()
@@ -350,24 +408,25 @@ func (server *Server) rejectRideRequest(c *gin.Context) {
 			return nil, result.Err()
 		}
 
-		// * Update the status of the request to rejected
-		filter := bson.M{"_id": request_id}
-		update := bson.M{"$set": bson.M{"status": 2}}
-		result = server.collection.Request.FindOneAndUpdate(c, filter, update)
-		if result.Err() != nil {
-			return nil, result.Err()
+		// * Update the status of the request to rejected, the request must belong to the ride
+		var request models.RequestToDriverRes
+		filter := bson.M{"_id": request_id, "ride_id": ride_id}
+		update := bson.M{"$set": bson.M{"status": models.RequestRejected}}
+		if err := server.collection.Request.FindOneAndUpdate(c, filter, update).Decode(&request); err != nil {
+			return nil, err
 		}
 
 		// * Send notification to the passenger that the request has been rejected
-		msg := fmt.Sprintf("Your request for ride has been DECLINED by the driver %s", authPayload.Name)
-
-		notification := models.NotificationModel{
-			Email:       req.Email,
-			Content:     msg,
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       request.Email,
 			Timestamp:   time.Now().Unix(),
 			SenderPhone: authPayload.Phone,
 			SenderName:  authPayload.Name,
-			Type:        2,
+			DriverName:  authPayload.Name,
+			RideID:      req.RideID,
+			RequestID:   req.RequestID,
+			Type:        models.NotificationRequestRejected,
 		}
 
 		_, err = server.collection.Notification.InsertOne(c, notification)
@@ -388,6 +447,8 @@ func (server *Server) rejectRideRequest(c *gin.Context) {
 		return
 	}
 
+	server.deliverNotifications(notification)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Request Declined Successfully"})
 }
 
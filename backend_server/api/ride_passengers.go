@@ -0,0 +1,243 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const defaultCancellationCutoff = time.Hour
+
+var errCancellationCutoff = errors.New("ride departs too soon to change its passengers")
+
+// cancellationCutoff is how long before departure passengers can no longer leave or be removed.
+func (server *Server) cancellationCutoff() time.Duration {
+	if server.config.RideCancellationCutoff > 0 {
+		return server.config.RideCancellationCutoff
+	}
+	return defaultCancellationCutoff
+}
+
+// removePassenger pulls the passenger out of the ride matching filter and sets
+// the status of their accepted request. Seats are counted from the passengers
+// so the seat is freed as well. Passengers that still have to confirm a price
+// increase, or whose ride was rescheduled or rerouted, are not bound by the
+// cancellation cutoff.
+func (server *Server) removePassenger(sessCtx mongo.SessionContext, filter bson.M, email string, status models.RequestStatus) (models.CreateRideResp, error) {
+	var ride models.CreateRideResp
+
+	if err := server.collection.Ride.FindOne(sessCtx, filter, nextRideOptions()).Decode(&ride); err != nil {
+		return ride, err
+	}
+
+	exempt := false
+	for _, passenger := range ride.Passengers {
+		if passenger.Email == email {
+			exempt = passenger.PendingConfirmation || passenger.RideChanged
+		}
+	}
+
+	if !exempt && time.Until(time.Unix(ride.Timestamp, 0)) < server.cancellationCutoff() {
+		return ride, errCancellationCutoff
+	}
+
+	objectId, err := utils.StringToObjectId(ride.Id)
+	if err != nil {
+		return ride, err
+	}
+
+	update := bson.M{"$pull": bson.M{"passengers": bson.M{"email": email}}}
+	if _, err := server.collection.Ride.UpdateOne(sessCtx, bson.M{"_id": objectId}, update); err != nil {
+		return ride, err
+	}
+
+	filter2 := bson.M{"ride_id": objectId, "email": email, "status": models.RequestAccepted}
+	update2 := bson.M{"$set": bson.M{"status": status}}
+	if _, err := server.collection.Request.UpdateOne(sessCtx, filter2, update2); err != nil {
+		return ride, err
+	}
+
+	return ride, nil
+}
+
+// passengerLeave removes the logged in passenger from the ride matching filter
+// and lets the driver know.
+func (server *Server) passengerLeave(c *gin.Context, filter bson.M) {
+	var notification models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// * The driver cannot leave their own ride
+	filter["status"] = statusIn(models.RideScheduled, models.RideBoarding)
+	filter["email"] = bson.M{"$ne": authPayload.Email}
+	filter["passengers.email"] = authPayload.Email
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		ride, err := server.removePassenger(sessCtx, filter, authPayload.Email, models.RequestWithdrawn)
+		if err != nil {
+			return nil, err
+		}
+
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       ride.Email,
+			SenderPhone: authPayload.Phone,
+			SenderName:  authPayload.Name,
+			RideID:      ride.Id,
+			Timestamp:   time.Now().Unix(),
+			Type:        models.NotificationPassengerWithdrew,
+		}
+		if _, err := server.collection.Notification.InsertOne(sessCtx, notification); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errCancellationCutoff {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "left the ride"})
+}
+
+// withdrawFromRide removes the passenger from a ride after the driver changed it.
+func (server *Server) withdrawFromRide(c *gin.Context) {
+	var req models.RideIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RideID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.passengerLeave(c, bson.M{"_id": objectId})
+}
+
+// leaveRide removes the passenger from their current ride.
+func (server *Server) leaveRide(c *gin.Context) {
+	server.passengerLeave(c, bson.M{})
+}
+
+// removeRidePassenger lets the driver remove an accepted passenger from their ride.
+func (server *Server) removeRidePassenger(c *gin.Context) {
+	var req models.RemovePassengerReq
+	var notification models.NotificationModel
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RideID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.Email == authPayload.Email {
+		err := errors.New("driver cannot be removed from their own ride")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.M{
+			"_id":              objectId,
+			"status":           statusIn(models.RideScheduled, models.RideBoarding),
+			"email":            authPayload.Email,
+			"passengers.email": req.Email,
+		}
+
+		ride, err := server.removePassenger(sessCtx, filter, req.Email, models.RequestRemoved)
+		if err != nil {
+			return nil, err
+		}
+
+		notification = models.NotificationModel{
+			ID:          primitive.NewObjectID(),
+			Email:       req.Email,
+			SenderPhone: authPayload.Phone,
+			SenderName:  authPayload.Name,
+			DriverName:  authPayload.Name,
+			RideID:      ride.Id,
+			Timestamp:   time.Now().Unix(),
+			Type:        models.NotificationPassengerRemoved,
+		}
+		if _, err := server.collection.Notification.InsertOne(sessCtx, notification); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errCancellationCutoff {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.deliverNotifications(notification)
+
+	c.JSON(http.StatusOK, gin.H{"message": "passenger removed from ride"})
+}
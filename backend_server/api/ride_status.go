@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errInvalidRideTransition = errors.New("ride cannot move to this status")
+	errPendingConfirmation   = errors.New("passengers have not confirmed the new price yet")
+)
+
+// statusIn matches rides in any of the given statuses.
+func statusIn(statuses ...models.RideStatus) bson.M {
+	return bson.M{"$in": statuses}
+}
+
+// transitionRide moves the ride matching filter to next, recording when it
+// happened along with any extra fields in set.
+func (server *Server) transitionRide(ctx context.Context, filter bson.M, next models.RideStatus, set bson.M) (models.CreateRideResp, error) {
+	var ride models.CreateRideResp
+
+	// * Only rides that can move to next are read, so the filter matching an
+	// * earlier ride that already moved on does not hide the next one
+	filter2 := bson.M{"$and": bson.A{filter, bson.M{"status": statusIn(next.PreviousStatuses()...)}}}
+
+	if err := server.collection.Ride.FindOne(ctx, filter2, nextRideOptions()).Decode(&ride); err != nil {
+		if err == mongo.ErrNoDocuments && server.collection.Ride.FindOne(ctx, filter).Err() == nil {
+			return ride, errInvalidRideTransition
+		}
+		return ride, err
+	}
+
+	// * Passengers asked about a price increase have not agreed to ride yet,
+	// * they confirm or withdraw before the ride starts
+	if next == models.RideInProgress {
+		for _, passenger := range ride.Passengers {
+			if passenger.PendingConfirmation {
+				return ride, errPendingConfirmation
+			}
+		}
+	}
+
+	objectId, err := utils.StringToObjectId(ride.Id)
+	if err != nil {
+		return ride, err
+	}
+
+	if set == nil {
+		set = bson.M{}
+	}
+	set["status"] = next
+	set[next.TimestampField()] = time.Now().Unix()
+
+	// * Only update if the status did not change since it was read
+	filter3 := bson.M{"_id": objectId, "status": ride.Status}
+	if next == models.RideInProgress {
+		filter3["passengers.pending_confirmation"] = bson.M{"$ne": true}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = server.collection.Ride.FindOneAndUpdate(ctx, filter3, bson.M{"$set": set}, opts).Decode(&ride)
+	if err == mongo.ErrNoDocuments {
+		return ride, errInvalidRideTransition
+	}
+
+	return ride, err
+}
+
+// driverTransition moves a ride of the logged in driver to next.
+func (server *Server) driverTransition(c *gin.Context, next models.RideStatus) {
+	var req models.RideIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RideID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"_id": objectId, "email": authPayload.Email}
+	ride, err := server.transitionRide(c, filter, next, nil)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errInvalidRideTransition || err == errPendingConfirmation {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, ride)
+}
+
+// boardRide marks that the driver started picking up passengers.
+func (server *Server) boardRide(c *gin.Context) {
+	server.driverTransition(c, models.RideBoarding)
+}
+
+func (server *Server) startRide(c *gin.Context) {
+	server.driverTransition(c, models.RideInProgress)
+}
+
+func (server *Server) endRide(c *gin.Context) {
+	var req models.RideIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RideID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	server.finishRide(c, bson.M{"_id": objectId, "email": authPayload.Email})
+}
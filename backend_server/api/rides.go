@@ -2,13 +2,13 @@ package api
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/achintya-7/car_pooling_backend/mapsApi"
 	"github.com/achintya-7/car_pooling_backend/models"
 	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,6 +18,74 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
+const defaultRescheduleThreshold = 15 * time.Minute
+
+var (
+	errSeatsBelowPassengers = errors.New("seats cannot be less than the passengers already in the ride")
+	errRideInPast           = errors.New("ride cannot depart in the past")
+	errRideOverlap          = errors.New("ride already exists at this time")
+)
+
+// rideGeoJSON stores the whole route as a LineString so that rides can be
+// matched anywhere along it, falling back to the steps without a polyline.
+func rideGeoJSON(route mapsApi.Route) primitive.M {
+	points := route.Path
+	if len(points) < 2 {
+		points = route.Points
+	}
+
+	// * Rounded polylines can repeat a position, which a LineString does not allow
+	var coordinates [][]float64
+	for _, point := range points {
+		last := len(coordinates) - 1
+		if last >= 0 && coordinates[last][0] == point.Lng && coordinates[last][1] == point.Lat {
+			continue
+		}
+		coordinates = append(coordinates, []float64{point.Lng, point.Lat})
+	}
+
+	if len(coordinates) < 2 {
+		return primitive.M{"type": "MultiPoint", "coordinates": coordinates}
+	}
+
+	return primitive.M{"type": "LineString", "coordinates": coordinates}
+}
+
+// nextRideOptions picks the ride departing first when a filter matches several.
+func nextRideOptions() *options.FindOneOptions {
+	return options.FindOne().SetSort(bson.M{"timestamp": 1})
+}
+
+// driverRideFilter matches the ride in the ride_id path parameter, or every
+// ride of the driver on routes without one.
+func driverRideFilter(c *gin.Context, email string) (bson.M, error) {
+	filter := bson.M{"email": email}
+
+	if rideID := c.Param("ride_id"); rideID != "" {
+		objectId, err := utils.StringToObjectId(rideID)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = objectId
+	}
+
+	return filter, nil
+}
+
+// overlapFilter matches rides departing within the overlap window of timestamp.
+func overlapFilter(timestamp int64) bson.M {
+	window := int64(models.RideOverlapWindow / time.Second)
+	return bson.M{"$gte": timestamp - window, "$lte": timestamp + window}
+}
+
+// rescheduleThreshold is how far the departure can move before passengers are notified.
+func (server *Server) rescheduleThreshold() time.Duration {
+	if server.config.RideRescheduleThreshold > 0 {
+		return server.config.RideRescheduleThreshold
+	}
+	return defaultRescheduleThreshold
+}
+
 func (server *Server) createRide(c *gin.Context) {
 	var req models.CreateRideReq
 	var result models.CreateDriverResponse
@@ -30,25 +98,34 @@ func (server *Server) createRide(c *gin.Context) {
 
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	filter := bson.M{"email": authPayload.Email, "complete": false}
+	verified, err := server.isPhoneVerified(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusForbidden, errorResponse(errPhoneNotVerified))
+		return
+	}
+
+	filter := bson.M{
+		"email":     authPayload.Email,
+		"status":    statusIn(models.ActiveRideStatuses...),
+		"timestamp": overlapFilter(req.Timestamp),
+	}
 	err = server.collection.Ride.FindOne(c, filter).Decode(&result)
 	if err == nil || result.Email != "" {
-		err := errors.New("ride already exists")
-		c.JSON(http.StatusConflict, errorResponse(err))
+		c.JSON(http.StatusConflict, errorResponse(errRideOverlap))
 		return
 	}
 
 	// * Dont put in Go routine to prevent unncecessary calls to google maps api
-	placeRoute, err := mapsApi.GetRoute(req.Origin, req.Destination, server.config)
+	placeRoute, err := server.maps.Route(mapsContext(c), req.Origin, req.Destination)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
 		return
 	}
 
-	originPoint := placeRoute.Points[0]
-	middlePoint := placeRoute.Points[len(placeRoute.Points)/2]
-	destinationPoint := placeRoute.Points[len(placeRoute.Points)-1]
-
 	response := models.CreateRideResp{
 		Origin:      req.Origin,
 		Destination: req.Destination,
@@ -58,15 +135,8 @@ func (server *Server) createRide(c *gin.Context) {
 		Price:       req.Price,
 		PlaceId:     req.PlaceId,
 		Timestamp:   req.Timestamp,
-		Complete:    false,
-		GeoJSON: primitive.M{
-			"type": "MultiPoint",
-			"coordinates": [][]float64{
-				{originPoint.Lng, originPoint.Lat},
-				{middlePoint.Lng, middlePoint.Lat},
-				{destinationPoint.Lng, destinationPoint.Lat},
-			},
-		},
+		Status:      models.RideScheduled,
+		GeoJSON:     rideGeoJSON(placeRoute),
 		Passengers: []models.Passenger{
 			{
 				RequestID: "0",
@@ -90,16 +160,32 @@ func (server *Server) createRide(c *gin.Context) {
 
 }
 
+// deleteRide cancels the ride, or the next ride of the driver when no ride id is
+// given. The ride is kept with the reason so that passengers can still see
+// what happened.
 func (server *Server) deleteRide(c *gin.Context) {
+	var req models.CancelRideReq
 	var result models.CreateRideResp
-	var notifications []interface{}
+	var notifications []models.NotificationModel
 
 	wc := writeconcern.New(writeconcern.WMajority())
 	rc := readconcern.Snapshot()
 	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
 
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	filter, err := driverRideFilter(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	filter["status"] = statusIn(models.ActiveRideStatuses...)
+
 	session, err := server.client.StartSession()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -109,30 +195,45 @@ func (server *Server) deleteRide(c *gin.Context) {
 
 	// * Callback function for transaction
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// * Delete ride
-		filter := bson.M{"email": authPayload.Email, "complete": false}
-		err = server.collection.Ride.FindOneAndDelete(c, filter).Decode(&result)
+		// * Cancel ride
+		result, err = server.transitionRide(sessCtx, filter, models.RideCancelled, bson.M{"cancel_reason": req.Reason})
 		if err != nil {
 			return nil, err
 		}
 
+		ride_id, err := utils.StringToObjectId(result.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		// * Pending and accepted requests can no longer go anywhere
+		filter2 := bson.M{"ride_id": ride_id, "status": bson.M{"$in": []models.RequestStatus{models.RequestPending, models.RequestAccepted}}}
+		update2 := bson.M{"$set": bson.M{"status": models.RequestCancelled}}
+		if _, err := server.collection.Request.UpdateMany(sessCtx, filter2, update2); err != nil {
+			return nil, err
+		}
+
 		// * Send notification to all passengers that ride has been cancelled
-		msg := fmt.Sprintf("Ride has been cancelled by driver %s", authPayload.Email)
 		t := time.Now().Unix()
 
+		notifications = nil
+		var docs []interface{}
 		for _, passenger := range result.Passengers {
 			notification := models.NotificationModel{
+				ID:          primitive.NewObjectID(),
 				Email:       passenger.Email,
 				SenderPhone: authPayload.Phone,
 				SenderName:  authPayload.Name,
-				Content:     msg,
+				DriverName:  authPayload.Name,
+				RideID:      result.Id,
 				Timestamp:   t,
-				Type:        3,
+				Type:        models.NotificationRideCancelled,
 			}
 			notifications = append(notifications, notification)
+			docs = append(docs, notification)
 		}
 
-		_, err = server.collection.Notification.InsertMany(c, notifications)
+		_, err = server.collection.Notification.InsertMany(c, docs)
 		if err != nil {
 			return nil, err
 		}
@@ -146,11 +247,17 @@ func (server *Server) deleteRide(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, errorResponse(err))
 			return
 		}
+		if err == errInvalidRideTransition {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ride deleted successfully"})
+	server.deliverNotifications(notifications...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "ride cancelled successfully"})
 }
 
 func (server *Server) getAllRidesDriver(c *gin.Context) {
@@ -181,6 +288,35 @@ func (server *Server) getAllRidesDriver(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// getUpcomingRidesDriver lists the rides of the driver that have not ended, next departure first.
+func (server *Server) getUpcomingRidesDriver(c *gin.Context) {
+	var result []models.CreateRideResp
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"email": authPayload.Email, "status": statusIn(models.ActiveRideStatuses...)}
+	opts := options.Find().SetSort(bson.M{"timestamp": 1})
+
+	cursor, err := server.collection.Ride.Find(c, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = cursor.All(c, &result)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if len(result) == 0 {
+		c.JSON(http.StatusNotFound, []models.CreateRideResp{})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (server *Server) getAllRidesPassenger(c *gin.Context) {
 	var result []models.CreateRideResp
 
@@ -210,155 +346,418 @@ func (server *Server) getAllRidesPassenger(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// TODO : Check all the conditions before updating the ride, otherwise skip it
-// func (server *Server) updateRide(c *gin.Context) {
-// 	var req models.UpdateRideReq
-// 	var result models.CreateRideResp
+func (server *Server) updateRide(c *gin.Context) {
+	var req models.UpdateRideReq
+	var ride models.CreateRideResp
+	var placeRoute mapsApi.Route
+	var notifications []models.NotificationModel
 
-// 	err := c.ShouldBindJSON(&req)
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, errorResponse(err))
-// 		return
-// 	}
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
 
-// 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-// 	pByte, err := bson.Marshal(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, errorResponse(err))
-// 		return
-// 	}
+	if req.Origin == nil && req.Destination == nil && req.PlaceId == nil && req.Seats == nil && req.Price == nil && req.Timestamp == nil {
+		err := errors.New("nothing to update")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
-// 	var updateDoc bson.M
+	if req.Timestamp != nil && *req.Timestamp <= time.Now().Unix() {
+		c.JSON(http.StatusBadRequest, errorResponse(errRideInPast))
+		return
+	}
 
-// 	err = bson.Unmarshal(pByte, &updateDoc)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, errorResponse(err))
-// 		return
-// 	}
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
-// 	update := bson.M{"$set": updateDoc}
-// 	filter := bson.M{"email": authPayload.Email, "complete": false}
-// 	err = server.collection.Ride.FindOneAndUpdate(c, filter, update).Decode(&result)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, errorResponse(err))
-// 		return
-// 	}
+	filter, err := driverRideFilter(c, authPayload.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	filter["status"] = models.RideScheduled
 
-// 	c.JSON(http.StatusOK, result)
-// }
+	err = server.collection.Ride.FindOne(c, filter, nextRideOptions()).Decode(&ride)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
-func (server *Server) completeRide(c *gin.Context) {
+	// * Keep working on the same ride even if another one is scheduled earlier
+	objectId, err := utils.StringToObjectId(ride.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	filter = bson.M{"_id": objectId, "email": authPayload.Email, "status": models.RideScheduled}
 
-	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+	origin, destination := ride.Origin, ride.Destination
+	if req.Origin != nil {
+		origin = *req.Origin
+	}
+	if req.Destination != nil {
+		destination = *req.Destination
+	}
+	routeChanged := origin != ride.Origin || destination != ride.Destination
 
-	filter := bson.M{"email": authPayload.Email, "complete": false}
-	update := bson.M{"$set": bson.M{"complete": true}}
+	// * Dont call the maps api inside the transaction, it may be retried
+	if routeChanged {
+		placeRoute, err = server.maps.Route(mapsContext(c), origin, destination)
+		if err != nil {
+			c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
+			return
+		}
+	}
 
-	err := server.collection.Ride.FindOneAndUpdate(c, filter, update)
-	if err.Err() != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err.Err()))
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	defer session.EndSession(c)
 
-	// TODO : send notification to all passengers that ride has been completed
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var current models.CreateRideResp
 
-	c.JSON(http.StatusOK, gin.H{"message": "ride completed successfully"})
-}
+		// * Read the ride again, passengers may have been accepted in the meantime
+		if err := server.collection.Ride.FindOne(sessCtx, filter).Decode(&current); err != nil {
+			return nil, err
+		}
 
-func (server *Server) getCurrentRideDriver(c *gin.Context) {
-	var result models.CreateRideResp
+		set := bson.M{}
 
-	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+		if req.Seats != nil {
+			if *req.Seats < len(current.Passengers) {
+				return nil, errSeatsBelowPassengers
+			}
+			set["seats"] = *req.Seats
+		}
+
+		priceIncreased := req.Price != nil && *req.Price > current.Price
+		if req.Price != nil {
+			set["price"] = *req.Price
+		}
+
+		rescheduled := false
+		if req.Timestamp != nil {
+			// * Like createRide and createRequest, neither the driver nor the
+			// * passengers can be on another ride around the new time
+			var emails []string
+			for _, passenger := range current.Passengers {
+				emails = append(emails, passenger.Email)
+			}
+
+			filter2 := bson.M{
+				"_id":              bson.M{"$ne": objectId},
+				"status":           statusIn(models.ActiveRideStatuses...),
+				"passengers.email": bson.M{"$in": emails},
+				"timestamp":        overlapFilter(*req.Timestamp),
+			}
+			err := server.collection.Ride.FindOne(sessCtx, filter2).Err()
+			if err == nil {
+				return nil, errRideOverlap
+			}
+			if err != mongo.ErrNoDocuments {
+				return nil, err
+			}
 
-	filter := bson.M{"email": authPayload.Email, "complete": false}
-	err := server.collection.Ride.FindOne(c, filter).Decode(&result)
+			shift := time.Duration(*req.Timestamp-current.Timestamp) * time.Second
+			rescheduled = shift > server.rescheduleThreshold() || -shift > server.rescheduleThreshold()
+			set["timestamp"] = *req.Timestamp
+		}
+
+		if req.PlaceId != nil {
+			set["placeid"] = *req.PlaceId
+		}
+
+		if routeChanged {
+			set["origin"] = origin
+			set["destination"] = destination
+			set["geojson"] = rideGeoJSON(placeRoute)
+
+			// * The driver is stored as the first passenger of the ride
+			current.Passengers[0].Origin = origin
+			current.Passengers[0].OriginLat = placeRoute.Points[0].Lat
+			current.Passengers[0].OriginLng = placeRoute.Points[0].Lng
+			set["passengers"] = current.Passengers
+		}
+
+		// * Accepted passengers have to confirm the new price, and can still leave
+		// * a ride that was moved or rerouted close to departure
+		if priceIncreased || rescheduled || routeChanged {
+			for i := range current.Passengers {
+				if current.Passengers[i].Email == current.Email {
+					continue
+				}
+				if priceIncreased {
+					current.Passengers[i].PendingConfirmation = true
+				}
+				if rescheduled || routeChanged {
+					current.Passengers[i].RideChanged = true
+				}
+			}
+			set["passengers"] = current.Passengers
+		}
+
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := server.collection.Ride.FindOneAndUpdate(sessCtx, filter, bson.M{"$set": set}, opts).Decode(&ride)
+		if err != nil {
+			return nil, err
+		}
+
+		var kinds []models.NotificationKind
+		if priceIncreased {
+			kinds = append(kinds, models.NotificationRidePriceIncreased)
+		}
+		if rescheduled {
+			kinds = append(kinds, models.NotificationRideRescheduled)
+		}
+		if routeChanged {
+			kinds = append(kinds, models.NotificationRideRouteChanged)
+		}
+
+		t := time.Now().Unix()
+
+		notifications = nil
+		var docs []interface{}
+		for _, passenger := range ride.Passengers {
+			if passenger.Email == ride.Email {
+				continue
+			}
+
+			for _, kind := range kinds {
+				notification := models.NotificationModel{
+					ID:          primitive.NewObjectID(),
+					Email:       passenger.Email,
+					SenderPhone: authPayload.Phone,
+					SenderName:  authPayload.Name,
+					DriverName:  authPayload.Name,
+					RideID:      ride.Id,
+					Price:       ride.Price,
+					Departure:   ride.Timestamp,
+					Timestamp:   t,
+					Type:        kind,
+				}
+				notifications = append(notifications, notification)
+				docs = append(docs, notification)
+			}
+		}
+
+		if len(docs) > 0 {
+			if _, err := server.collection.Notification.InsertMany(sessCtx, docs); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errSeatsBelowPassengers {
 			c.JSON(http.StatusBadRequest, errorResponse(err))
 			return
 		}
+		if err == errRideOverlap {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	server.deliverNotifications(notifications...)
+
+	c.JSON(http.StatusOK, ride)
 }
 
-func (server *Server) getCurrentRidePassengers(c *gin.Context) {
-	var result models.CreateRideResp
+// confirmRide accepts the new price of a ride the passenger is part of.
+func (server *Server) confirmRide(c *gin.Context) {
+	var req models.RideIDReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	objectId, err := utils.StringToObjectId(req.RideID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
 	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	filter := bson.M{
-		"complete": false,
+		"_id":    objectId,
+		"status": statusIn(models.ActiveRideStatuses...),
 		"passengers": bson.M{
 			"$elemMatch": bson.M{
-				"email": authPayload.Email,
+				"email":                authPayload.Email,
+				"pending_confirmation": true,
 			},
 		},
 	}
+	update := bson.M{"$unset": bson.M{"passengers.$.pending_confirmation": ""}}
 
-	err := server.collection.Ride.FindOne(c, filter).Decode(&result)
+	result, err := server.collection.Ride.UpdateOne(c, filter, update)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusBadRequest, errorResponse(err))
-			return
-		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	if result.MatchedCount == 0 {
+		err := errors.New("no pending confirmation for this ride")
+		c.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ride confirmed"})
 }
 
-func (server *Server) searchRide(c *gin.Context) {
-	var result []models.CreateRideResp
+// completeRide ends the ride that the driver is currently on.
+func (server *Server) completeRide(c *gin.Context) {
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	server.finishRide(c, bson.M{"email": authPayload.Email, "status": models.RideInProgress})
+}
 
-	var req models.SearchRideReq
+// finishRide completes the ride matching filter, stores its summary and lets
+// the passengers know.
+func (server *Server) finishRide(c *gin.Context, filter bson.M) {
+	var ride models.CreateRideResp
+	var summary models.RideSummary
+	var notifications []models.NotificationModel
 
-	err := c.ShouldBindUri(&req)
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// * Mark the ride as complete
+		ride, err = server.transitionRide(sessCtx, filter, models.RideCompleted, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// * Store the per passenger summary
+		summary = buildRideSummary(ride, authPayload.Name)
+		if _, err := server.collection.RideSummary.InsertOne(sessCtx, summary); err != nil {
+			return nil, err
+		}
+
+		// * Send notification to all passengers that ride has been completed
+		notifications = nil
+		var docs []interface{}
+		for _, passenger := range ride.Passengers {
+			if passenger.Email == ride.Email {
+				continue
+			}
 
-	point, err := mapsApi.GetCords(req.Origin, server.config)
+			notification := models.NotificationModel{
+				ID:          primitive.NewObjectID(),
+				Email:       passenger.Email,
+				SenderPhone: authPayload.Phone,
+				SenderName:  authPayload.Name,
+				DriverName:  authPayload.Name,
+				RideID:      ride.Id,
+				Timestamp:   ride.CompletedAt,
+				Type:        models.NotificationRideCompleted,
+			}
+			notifications = append(notifications, notification)
+			docs = append(docs, notification)
+		}
+
+		if len(docs) > 0 {
+			if _, err := server.collection.Notification.InsertMany(sessCtx, docs); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == errInvalidRideTransition {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	pipeline := []bson.M{
-		{
-			"$geoNear": bson.M{
-				"near": bson.M{
-					"type":        "MultiPoint",
-					"coordinates": []float64{point.Lng, point.Lat},
-				},
-				"maxDistance":   5000,
-				"spherical":     true,
-				"distanceField": "distance",
-			},
-		},
-		{
-			"$match": bson.M{
-				"complete": false,
-			},
-		},
-	}
+	server.deliverNotifications(notifications...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "ride completed successfully", "summary": summary})
+}
 
-	cursor, err := server.collection.Ride.Aggregate(c, pipeline)
+func (server *Server) getCurrentRideDriver(c *gin.Context) {
+	var result models.CreateRideResp
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{"email": authPayload.Email, "status": statusIn(models.ActiveRideStatuses...)}
+	err := server.collection.Ride.FindOne(c, filter, nextRideOptions()).Decode(&result)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	defer cursor.Close(c)
 
-	cursor.All(c, &result)
+	c.JSON(http.StatusOK, result)
+}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, []models.CreateDriverResponse{})
+func (server *Server) getCurrentRidePassengers(c *gin.Context) {
+	var result models.CreateRideResp
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	filter := bson.M{
+		"status": statusIn(models.ActiveRideStatuses...),
+		"passengers": bson.M{
+			"$elemMatch": bson.M{
+				"email": authPayload.Email,
+			},
+		},
+	}
+
+	err := server.collection.Ride.FindOne(c, filter, nextRideOptions()).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
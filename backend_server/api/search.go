@@ -0,0 +1,411 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/mapsApi"
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultSearchRadius = 5000 // meters
+	defaultSearchLimit  = 20
+	// * Rides read per batch for each ride of the page, route matching drops some
+	searchBatchFactor = 2
+	maxSearchBatches  = 5
+	// * Rides ranked by distance are sorted in memory, the closest ones to the point
+	maxDistanceCandidates = 500
+)
+
+var errInvalidSearchCursor = errors.New("invalid cursor")
+
+// matchRoute fills in where the passenger joins the ride. With a drop-off it
+// reports false unless the route reaches the pickup before the drop-off and
+// passes within radius of both.
+func matchRoute(ride *models.SearchRideResult, pickup mapsApi.Point, dropoff *mapsApi.Point, radius float64) bool {
+	coordinates := utils.GeoJSONCoordinates(ride.GeoJSON)
+
+	pickupPosition, pickupSegment, pickupDistance := utils.ProjectOnPath(coordinates, pickup.Lat, pickup.Lng)
+	if pickupPosition == nil {
+		return false
+	}
+
+	ride.PickupLat, ride.PickupLng = pickupPosition[1], pickupPosition[0]
+	ride.Detour = utils.Detour(coordinates, pickup.Lat, pickup.Lng)
+	ride.WalkingDistance = pickupDistance
+
+	if dropoff == nil {
+		return true
+	}
+
+	dropoffPosition, dropoffSegment, dropoffDistance := utils.ProjectOnPath(coordinates, dropoff.Lat, dropoff.Lng)
+	if dropoffDistance > radius {
+		return false
+	}
+
+	// * Rides going the other way reach the drop-off first
+	if utils.DistanceAlong(coordinates, pickupSegment, pickupPosition) >= utils.DistanceAlong(coordinates, dropoffSegment, dropoffPosition) {
+		return false
+	}
+
+	ride.DropoffLat, ride.DropoffLng = dropoffPosition[1], dropoffPosition[0]
+	ride.WalkingDistance += dropoffDistance
+
+	return true
+}
+
+// meetsMinRating lets drivers nobody rated yet through, new drivers would
+// never be found otherwise.
+func meetsMinRating(ride models.SearchRideResult, minRating float64) bool {
+	return ride.DriverRatingCount == 0 || ride.DriverRating >= minRating
+}
+
+type searchCursor struct {
+	Sort string  `json:"s"`
+	Key  float64 `json:"k"`
+	ID   string  `json:"id"`
+}
+
+func encodeSearchCursor(cursor searchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(encoded string) (searchCursor, error) {
+	var cursor searchCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, errInvalidSearchCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errInvalidSearchCursor
+	}
+
+	return cursor, nil
+}
+
+// searchSortField is the ride field the results are sorted by in the
+// database, empty for distance which is only known after route matching.
+func searchSortField(sortBy string) string {
+	switch sortBy {
+	case "time":
+		return "timestamp"
+	case "price":
+		return "price"
+	}
+	return ""
+}
+
+// searchSortKey is the value the ride is ranked by. Distance is the meters the
+// passenger walks with a destination, the detour of the driver otherwise.
+func searchSortKey(ride models.SearchRideResult, sortBy string, withDestination bool) float64 {
+	switch sortBy {
+	case "time":
+		return float64(ride.Timestamp)
+	case "price":
+		return float64(ride.Price)
+	}
+	if withDestination {
+		return ride.WalkingDistance
+	}
+	return ride.Detour
+}
+
+// rankByDistance sorts the matched rides by their distance key then id, and
+// keeps the ones after the cursor.
+func rankByDistance(rides []models.SearchRideResult, withDestination bool, after *searchCursor) []models.SearchRideResult {
+	sort.SliceStable(rides, func(i, j int) bool {
+		ki, kj := searchSortKey(rides[i], "distance", withDestination), searchSortKey(rides[j], "distance", withDestination)
+		if ki != kj {
+			return ki < kj
+		}
+		return rides[i].Id < rides[j].Id
+	})
+
+	if after == nil {
+		return rides
+	}
+
+	for i, ride := range rides {
+		key := searchSortKey(ride, "distance", withDestination)
+		if key > after.Key || (key == after.Key && ride.Id > after.ID) {
+			return rides[i:]
+		}
+	}
+	return nil
+}
+
+// afterCursorFilter matches the rides coming after the cursor in the sort
+// order, ties being ordered by id.
+func afterCursorFilter(field string, cursor searchCursor) (bson.M, error) {
+	id, err := utils.StringToObjectId(cursor.ID)
+	if err != nil {
+		return nil, errInvalidSearchCursor
+	}
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{field: bson.M{"$gt": cursor.Key}},
+			bson.M{field: cursor.Key, "_id": bson.M{"$gt": id}},
+		},
+	}
+
+	return filter, nil
+}
+
+// searchRide searches around the place, its coordinates are cached.
+func (server *Server) searchRide(c *gin.Context) {
+	var req models.SearchRidePlaceReq
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ctx := mapsApi.WithSessionToken(mapsContext(c), req.SessionToken)
+
+	point, err := server.maps.Coordinates(ctx, req.Origin)
+	if err != nil {
+		c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
+		return
+	}
+
+	server.searchRidesNear(c, point)
+}
+
+func (server *Server) searchRideByCoordinates(c *gin.Context) {
+	var req models.SearchRideCoordinatesReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.searchRidesNear(c, mapsApi.Point{Lat: *req.Lat, Lng: *req.Lng})
+}
+
+// searchRidesNear finds upcoming rides with free seats passing near the point,
+// with a destination only rides heading there are kept. By distance, the
+// closest rides are ranked in memory by the walk of the passenger with a
+// destination and the detour of the driver otherwise. By time or price, rides
+// are read from the database in batches sorted by the sort key then the ride
+// id, the route matching and rating filters being applied to each batch until
+// the page is full. The cursor is the last ride of the previous page.
+func (server *Server) searchRidesNear(c *gin.Context, point mapsApi.Point) {
+	var req models.SearchRideReq
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Sort == "" {
+		req.Sort = "distance"
+	}
+
+	var after *searchCursor
+	if req.Cursor != "" {
+		cursor, err := decodeSearchCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidSearchCursor))
+			return
+		}
+		after = &cursor
+	}
+
+	radius := req.Radius
+	if radius == 0 {
+		radius = defaultSearchRadius
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+	if req.MinSeats == 0 {
+		req.MinSeats = 1
+	}
+
+	var dropoff *mapsApi.Point
+	if req.Destination != "" {
+		ctx := mapsApi.WithSessionToken(mapsContext(c), req.DestinationSessionToken)
+
+		destination, err := server.maps.Coordinates(ctx, req.Destination)
+		if err != nil {
+			c.JSON(mapsErrorStatus(err), mapsErrorResponse(err))
+			return
+		}
+		dropoff = &destination
+	}
+
+	departure := bson.M{"$gt": time.Now().Unix()}
+	if req.DepartureAfter > 0 {
+		departure["$gte"] = req.DepartureAfter
+	}
+	if req.DepartureBefore > 0 {
+		departure["$lte"] = req.DepartureBefore
+	}
+
+	query := bson.M{
+		"status":    models.RideScheduled,
+		"timestamp": departure,
+	}
+	if req.MaxPrice > 0 {
+		query["price"] = bson.M{"$lte": req.MaxPrice}
+	}
+
+	field := searchSortField(req.Sort)
+	withDestination := dropoff != nil
+
+	// * One more ride than the page tells whether there is a next page
+	var matches []models.SearchRideResult
+
+	if field == "" {
+		// * The closest rides to the point are ranked by their route, the cursor
+		// * holds the distance key of the last ride of the page
+		result, err := server.searchBatch(c, point, radius, query, req.MinSeats, "distance", nil, maxDistanceCandidates)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for i := range result {
+			ride := result[i]
+			if meetsMinRating(ride, req.MinRating) && matchRoute(&ride, point, dropoff, radius) {
+				matches = append(matches, ride)
+			}
+		}
+
+		matches = rankByDistance(matches, withDestination, after)
+		if len(matches) > req.Limit+1 {
+			matches = matches[:req.Limit+1]
+		}
+		after = nil
+	}
+
+	batchSize := req.Limit * searchBatchFactor
+
+	for batch := 0; field != "" && batch < maxSearchBatches && len(matches) <= req.Limit; batch++ {
+		var afterFilter bson.M
+		if after != nil {
+			filter, err := afterCursorFilter(field, *after)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+			afterFilter = filter
+		}
+
+		result, err := server.searchBatch(c, point, radius, query, req.MinSeats, field, afterFilter, batchSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for i := range result {
+			ride := result[i]
+			after = &searchCursor{Sort: req.Sort, Key: searchSortKey(ride, req.Sort, withDestination), ID: ride.Id}
+
+			if !meetsMinRating(ride, req.MinRating) || !matchRoute(&ride, point, dropoff, radius) {
+				continue
+			}
+			if matches = append(matches, ride); len(matches) > req.Limit {
+				break
+			}
+		}
+
+		if len(result) < batchSize {
+			after = nil
+			break
+		}
+	}
+
+	resp := models.SearchRideResp{Rides: []models.SearchRideResult{}}
+
+	switch {
+	case len(matches) > req.Limit:
+		resp.Rides = matches[:req.Limit]
+		last := resp.Rides[len(resp.Rides)-1]
+		resp.NextCursor = encodeSearchCursor(searchCursor{Sort: req.Sort, Key: searchSortKey(last, req.Sort, withDestination), ID: last.Id})
+	case after != nil:
+		// * Gave up before the end, the next page carries on after the last ride read
+		resp.Rides = append(resp.Rides, matches...)
+		resp.NextCursor = encodeSearchCursor(*after)
+	default:
+		resp.Rides = append(resp.Rides, matches...)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// searchBatch reads up to limit upcoming rides with free seats passing within
+// radius of the point, sorted by field then id, along with their driver rating.
+func (server *Server) searchBatch(c *gin.Context, point mapsApi.Point, radius float64, query bson.M, minSeats int, field string, after bson.M, limit int) ([]models.SearchRideResult, error) {
+	var result []models.SearchRideResult
+
+	pipeline := []bson.M{
+		{
+			"$geoNear": bson.M{
+				"near": bson.M{
+					"type":        "Point",
+					"coordinates": []float64{point.Lng, point.Lat},
+				},
+				"maxDistance":   radius,
+				"spherical":     true,
+				"distanceField": "distance",
+				"query":         query,
+			},
+		},
+		{
+			"$match": bson.M{
+				"$expr": bson.M{
+					"$gte": bson.A{bson.M{"$subtract": bson.A{"$seats", bson.M{"$size": "$passengers"}}}, minSeats},
+				},
+			},
+		},
+	}
+
+	if after != nil {
+		pipeline = append(pipeline, bson.M{"$match": after})
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": limit},
+		bson.M{
+			"$lookup": bson.M{
+				"from":         server.collection.Driver.Name(),
+				"localField":   "email",
+				"foreignField": "email",
+				"as":           "driver",
+			},
+		},
+		bson.M{
+			"$addFields": bson.M{
+				"driver_rating":       bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$driver.rating", 0}}, 0}},
+				"driver_rating_count": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$driver.rating_count", 0}}, 0}},
+			},
+		},
+		bson.M{"$project": bson.M{"driver": 0}},
+	)
+
+	cursor, err := server.collection.Ride.Aggregate(c, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(c, &result)
+	return result, err
+}
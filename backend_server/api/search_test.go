@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSearchCursor(t *testing.T) {
+	cursor := searchCursor{Sort: "price", Key: 120, ID: primitive.NewObjectID().Hex()}
+
+	decoded, err := decodeSearchCursor(encodeSearchCursor(cursor))
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+
+	_, err = decodeSearchCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidSearchCursor)
+}
+
+func TestAfterCursorFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	filter, err := afterCursorFilter("price", searchCursor{Sort: "price", Key: 120, ID: id.Hex()})
+	require.NoError(t, err)
+	require.Equal(t, bson.M{
+		"$or": bson.A{
+			bson.M{"price": bson.M{"$gt": float64(120)}},
+			bson.M{"price": float64(120), "_id": bson.M{"$gt": id}},
+		},
+	}, filter)
+
+	_, err = afterCursorFilter("price", searchCursor{Sort: "price", Key: 120, ID: "nope"})
+	require.ErrorIs(t, err, errInvalidSearchCursor)
+}
+
+func TestSearchSortKey(t *testing.T) {
+	ride := models.SearchRideResult{Distance: 250, Detour: 400, WalkingDistance: 600}
+	ride.Timestamp = 1700000000
+	ride.Price = 80
+
+	require.Equal(t, "", searchSortField("distance"))
+	require.Equal(t, float64(400), searchSortKey(ride, "distance", false))
+	require.Equal(t, float64(600), searchSortKey(ride, "distance", true))
+	require.Equal(t, "timestamp", searchSortField("time"))
+	require.Equal(t, float64(1700000000), searchSortKey(ride, "time", true))
+	require.Equal(t, "price", searchSortField("price"))
+	require.Equal(t, float64(80), searchSortKey(ride, "price", false))
+}
+
+func TestRankByDistance(t *testing.T) {
+	newRide := func(id string, detour, walking float64) models.SearchRideResult {
+		ride := models.SearchRideResult{Detour: detour, WalkingDistance: walking}
+		ride.Id = id
+		return ride
+	}
+
+	rides := func() []models.SearchRideResult {
+		return []models.SearchRideResult{
+			newRide("a", 900, 100),
+			newRide("b", 100, 700),
+			newRide("c", 500, 300),
+			newRide("d", 100, 300),
+		}
+	}
+	ids := func(rides []models.SearchRideResult) []string {
+		var ids []string
+		for _, ride := range rides {
+			ids = append(ids, ride.Id)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"b", "d", "c", "a"}, ids(rankByDistance(rides(), false, nil)))
+	require.Equal(t, []string{"a", "c", "d", "b"}, ids(rankByDistance(rides(), true, nil)))
+
+	after := &searchCursor{Sort: "distance", Key: 300, ID: "c"}
+	require.Equal(t, []string{"d", "b"}, ids(rankByDistance(rides(), true, after)))
+
+	after = &searchCursor{Sort: "distance", Key: 900, ID: "a"}
+	require.Empty(t, rankByDistance(rides(), false, after))
+}
@@ -1,8 +1,20 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
 
+	"github.com/achintya-7/car_pooling_backend/firebase"
+	"github.com/achintya-7/car_pooling_backend/mapsApi"
+	"github.com/achintya-7/car_pooling_backend/notify"
+	"github.com/achintya-7/car_pooling_backend/pubsub"
+	"github.com/achintya-7/car_pooling_backend/scheduler"
+	"github.com/achintya-7/car_pooling_backend/sms"
+	"github.com/achintya-7/car_pooling_backend/templates"
 	"github.com/achintya-7/car_pooling_backend/token"
 	"github.com/achintya-7/car_pooling_backend/utils"
 	"github.com/gin-gonic/gin"
@@ -15,24 +27,227 @@ type Server struct {
 	tokenMaker token.Maker
 	client     *mongo.Client
 	collection utils.Collection
+	firebase   *firebase.Verifier
+	smsSender  sms.Sender
+	hub        *pubsub.Hub
+	dispatcher *notify.Dispatcher
+	templates  *templates.Registry
+	scheduler  *scheduler.Scheduler
+	maps       mapsApi.MapsProvider
+	// * Defaults of the place predictions, callers can move the bias
+	predictionOptions mapsApi.PredictionOptions
+	// * Done once Shutdown is called, stops the scheduler and the streams
+	shutdown   context.Context
+	httpServer *http.Server
+}
+
+func newTokenMaker(config utils.Config) (token.Maker, error) {
+	switch config.TokenType {
+	case "", "paseto_local":
+		return token.NewPasetoMaker(config.TokenSecret)
+	case "paseto_public":
+		return token.NewPasetoPublicMaker(config.TokenPrivateKey)
+	case "jwt":
+		return newJWTMaker(config)
+	default:
+		return nil, fmt.Errorf("unknown token type %s", config.TokenType)
+	}
+}
+
+// newJWTMaker signs with the current key and still accepts tokens signed by
+// the retired keys listed in JWT_VERIFICATION_KEYS.
+func newJWTMaker(config utils.Config) (token.Maker, error) {
+	readKey := func(secretOrFile string) ([]byte, error) {
+		if config.JWTAlgorithm == "" || config.JWTAlgorithm == "HS256" {
+			return []byte(secretOrFile), nil
+		}
+		return os.ReadFile(secretOrFile)
+	}
+
+	signingMaterial := []byte(config.TokenSecret)
+	if config.JWTPrivateKeyFile != "" {
+		var err error
+		if signingMaterial, err = os.ReadFile(config.JWTPrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("cannot read jwt private key : %v", err)
+		}
+	}
+
+	signingKey, err := token.ParseJWTKey(config.JWTAlgorithm, config.JWTKeyID, signingMaterial)
+	if err != nil {
+		return nil, err
+	}
+
+	var verificationKeys []token.JWTKey
+	for _, entry := range config.JWTVerificationKeys {
+		kid, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid jwt verification key %q, expected kid:value", entry)
+		}
+
+		material, err := readKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read jwt verification key %s : %v", kid, err)
+		}
+
+		key, err := token.ParseJWTKey(config.JWTAlgorithm, kid, material)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return token.NewJWTMaker(signingKey, verificationKeys...)
+}
+
+func newPushSender(config utils.Config) (notify.Sender, error) {
+	switch config.PushProvider {
+	case "", "log":
+		return notify.LogSender{}, nil
+	case "fcm":
+		return notify.NewFCMSender(config.FirebaseProjectID, config.FCMCredentialsFile)
+	default:
+		return nil, fmt.Errorf("unknown push provider %s", config.PushProvider)
+	}
+}
+
+// newMapsProvider puts a cache in front of the provider.
+func newMapsProvider(config utils.Config, collection utils.Collection) (mapsApi.MapsProvider, error) {
+	clientConfig := mapsApi.ClientConfig{
+		Timeout:          config.MapsTimeout,
+		MaxRetries:       config.MapsMaxRetries,
+		BreakerThreshold: config.MapsBreakerThreshold,
+		BreakerCooldown:  config.MapsBreakerCooldown,
+	}
+
+	name := config.MapsProvider
+	if name == "" {
+		name = "google"
+	}
+
+	var provider mapsApi.MapsProvider
+	switch name {
+	case "google":
+		provider = mapsApi.NewGoogleProvider(config.MapsKey, clientConfig)
+	case "osm":
+		osm, err := mapsApi.NewOSMProvider(config.NominatimURL, config.OSRMURL, clientConfig)
+		if err != nil {
+			return nil, err
+		}
+		provider = osm
+	case "fake":
+		provider = mapsApi.NewFakeProvider()
+	default:
+		return nil, fmt.Errorf("unknown maps provider %s", config.MapsProvider)
+	}
+
+	cacheConfig := mapsApi.CacheConfig{
+		Provider:       name,
+		Size:           config.MapsCacheSize,
+		PredictionsTTL: config.MapsPredictionsTTL,
+		PlaceTTL:       config.MapsPlaceTTL,
+		RouteTTL:       config.MapsRouteTTL,
+	}
+
+	switch config.MapsCacheStore {
+	case "", "memory":
+		return mapsApi.NewCache(provider, cacheConfig), nil
+	case "mongo":
+		return mapsApi.NewCache(provider, cacheConfig, mapsApi.NewMongoStore(collection.MapsCache)), nil
+	default:
+		return nil, fmt.Errorf("unknown maps cache store %s", config.MapsCacheStore)
+	}
+}
+
+func newPredictionOptions(config utils.Config) (mapsApi.PredictionOptions, error) {
+	options := mapsApi.PredictionOptions{
+		Countries: config.MapsCountries,
+		Limit:     config.MapsPredictionsLimit,
+	}
+
+	switch {
+	case config.MapsBiasLat != 0 || config.MapsBiasLng != 0:
+		options.Bias.Center = &mapsApi.Point{Lat: config.MapsBiasLat, Lng: config.MapsBiasLng}
+		options.Bias.Radius = config.MapsBiasRadius
+		if options.Bias.Radius == 0 {
+			options.Bias.Radius = defaultPredictionsBiasRadius
+		}
+	case len(config.MapsBiasBounds) == 4:
+		options.Bias.Bounds = &mapsApi.Bounds{
+			LatSW: config.MapsBiasBounds[0],
+			LngSW: config.MapsBiasBounds[1],
+			LatNE: config.MapsBiasBounds[2],
+			LngNE: config.MapsBiasBounds[3],
+		}
+	case len(config.MapsBiasBounds) != 0:
+		return options, fmt.Errorf("invalid maps bias bounds %v, expected south,west,north,east", config.MapsBiasBounds)
+	}
+
+	return options, nil
 }
 
 func NewServer(config utils.Config, client *mongo.Client) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSecret)
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token make : %v", err)
 	}
 
+	firebaseVerifier, err := firebase.NewVerifier(config.FirebaseProjectID, firebase.NewHTTPKeySource())
+	if err != nil {
+		return nil, fmt.Errorf("cannot create firebase verifier : %v", err)
+	}
+
+	smsSender, err := sms.NewSender(config.SMSProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	pushSender, err := newPushSender(config)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := templates.DefaultRegistry()
+	if err != nil {
+		return nil, err
+	}
+
 	collection := utils.NewCollection(client, config)
 
+	maps, err := newMapsProvider(config, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	predictionOptions, err := newPredictionOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyStore := notify.NewMongoStore(collection.Passenger, collection.NotificationDeadLetter)
+
 	server := &Server{
 		config:     config,
 		tokenMaker: tokenMaker,
 		client:     client,
 		collection: collection,
+		firebase:   firebaseVerifier,
+		smsSender:  smsSender,
+		hub:        pubsub.NewHub(notificationBufferSize),
+		dispatcher: notify.NewDispatcher(pushSender, notifyStore, registry, notify.DefaultDispatcherConfig()),
+		templates:  registry,
+		scheduler:  scheduler.New(collection.RecurringRide, collection.Ride, config.RecurringRideHorizon, config.SchedulerInterval),
+		maps:       maps,
+
+		predictionOptions: predictionOptions,
 	}
 
 	server.setupRoutes()
+
+	shutdown, cancel := context.WithCancel(context.Background())
+	server.shutdown = shutdown
+	server.httpServer = &http.Server{Handler: server.router}
+	server.httpServer.RegisterOnShutdown(cancel)
+
 	return server, nil
 }
 
@@ -44,46 +259,106 @@ func (server *Server) setupRoutes() {
 
 	r.GET("/", server.entryPoint)
 	r.POST("/passengers", server.createPassenger)
+	r.POST("/login", server.loginPassenger)
+	r.POST("/tokens/refresh", server.refreshToken)
+	r.GET("/tokens/public-key", server.tokenPublicKey)
 
 	// * AUTHENTICATION
-	authRoute := r.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoute := r.Group("/").Use(authMiddleware(server.tokenMaker, server.collection.RevokedToken))
+
+	// * Admins carry the admin role even when they drive, handlers check ownership by email
+	driverOnly := requireRole(token.RoleDriver, token.RoleAdmin)
+
+	authRoute.POST("/logout", server.logout)
 
 	// * PASSENGERS
 	authRoute.PUT("/passengers", server.updatePassenger)
 	authRoute.GET("/passengers", server.getPassenger)
 
+	// * VERIFICATION
+	authRoute.POST("/verify/phone/start", server.startPhoneVerification)
+	authRoute.POST("/verify/phone/confirm", server.confirmPhoneVerification)
+
 	// * DRIVERS
 	authRoute.POST("/drivers", server.cretaeDriver)
-	authRoute.PUT("/drivers", server.updateDriver)
-	authRoute.GET("/drivers", server.getDriver)
+	authRoute.PUT("/drivers", driverOnly, server.updateDriver)
+	authRoute.GET("/drivers", driverOnly, server.getDriver)
 
 	// * API
 	authRoute.GET("/api/placePredictions/:place", server.placePredicions)
 	authRoute.POST("/api/route", server.placeRoute)
+	authRoute.GET("/api/cache/stats", requireRole(token.RoleAdmin), server.mapsCacheStats)
 
 	// * RIDES
-	authRoute.POST("/rides", server.createRide)
-	authRoute.DELETE("/rides", server.deleteRide)
-	authRoute.GET("/rides/driver/all", server.getAllRidesDriver)
+	authRoute.POST("/rides", driverOnly, server.createRide)
+	authRoute.DELETE("/rides", driverOnly, server.deleteRide)
+	authRoute.DELETE("/rides/:ride_id", driverOnly, server.deleteRide)
+	authRoute.GET("/rides/driver/all", driverOnly, server.getAllRidesDriver)
+	authRoute.GET("/rides/driver/upcoming", driverOnly, server.getUpcomingRidesDriver)
 	authRoute.GET("/rides/passenger/all", server.getAllRidesPassenger)
-	// authRoute.PUT("/rides", server.updateRide)
-	authRoute.GET("/rides/driver", server.getCurrentRideDriver)
-	authRoute.GET("rides/complete", server.completeRide)
+	authRoute.PUT("/rides", driverOnly, server.updateRide)
+	authRoute.PUT("/rides/:ride_id", driverOnly, server.updateRide)
+	authRoute.POST("/rides/:ride_id/confirm", server.confirmRide)
+	authRoute.POST("/rides/:ride_id/withdraw", server.withdrawFromRide)
+	authRoute.DELETE("/rides/passengers/me", server.leaveRide)
+	authRoute.DELETE("/rides/:ride_id/passengers/:email", driverOnly, server.removeRidePassenger)
+	authRoute.GET("/rides/driver", driverOnly, server.getCurrentRideDriver)
+	authRoute.GET("rides/complete", driverOnly, server.completeRide)
+	authRoute.POST("/rides/:ride_id/board", driverOnly, server.boardRide)
+	authRoute.POST("/rides/:ride_id/start", driverOnly, server.startRide)
+	authRoute.POST("/rides/:ride_id/end", driverOnly, server.endRide)
 	authRoute.GET("/rides/passenger", server.getCurrentRidePassengers)
+	authRoute.GET("/rides/search", server.searchRideByCoordinates)
 	authRoute.GET("/rides/search/:place_id", server.searchRide)
+	authRoute.GET("/rides/:ride_id/summary", server.getRideSummary)
+	authRoute.POST("/rides/:ride_id/rating", server.rateRide)
+
+	// * RECURRING RIDES
+	authRoute.POST("/rides/recurring", driverOnly, server.createRecurringRide)
+	authRoute.GET("/rides/recurring", driverOnly, server.getRecurringRides)
+	authRoute.DELETE("/rides/recurring/:recurring_id", driverOnly, server.deleteRecurringRide)
+	authRoute.POST("/rides/recurring/:recurring_id/subscribe", server.subscribeRecurringRide)
+	authRoute.DELETE("/rides/recurring/:recurring_id/subscribe", server.unsubscribeRecurringRide)
+	authRoute.POST("/rides/recurring/:recurring_id/subscribers/:email/accept", driverOnly, server.acceptRecurringSubscriber)
+	authRoute.POST("/rides/recurring/:recurring_id/subscribers/:email/reject", driverOnly, server.rejectRecurringSubscriber)
+	authRoute.DELETE("/rides/recurring/:recurring_id/subscribers/:email", driverOnly, server.removeRecurringSubscriber)
 
 	// * REQUESTS
 	authRoute.POST("/requests", server.createRequest)
-	authRoute.GET("/requests/driver/:ride_id", server.getRideRequestsDriver)
+	authRoute.GET("/requests/driver/:ride_id", requireRole(token.RoleDriver, token.RoleAdmin), server.getRideRequestsDriver)
 	authRoute.GET("/requests/passenger", server.getRideRequestsPassenger)
-	authRoute.POST("/requests/accept", server.acceptRideRequest)
-	authRoute.POST("/requests/reject", server.rejectRideRequest)
+	authRoute.POST("/requests/accept", driverOnly, server.acceptRideRequest)
+	authRoute.POST("/requests/reject", driverOnly, server.rejectRideRequest)
 	authRoute.DELETE("/requests", server.deleteRideRequest)
 
+	// * NOTIFICATIONS
+	authRoute.GET("/notifications", server.getNotifications)
+	authRoute.GET("/notifications/stream", server.streamNotifications)
+	authRoute.POST("/notifications/read-all", server.markAllNotificationsRead)
+	authRoute.POST("/notifications/:id/read", server.markNotificationRead)
+	authRoute.DELETE("/notifications/:id", server.deleteNotification)
+
 }
 
+// Start serves requests until Shutdown is called.
 func (server *Server) Start(serverAddress string) error {
-	return server.router.Run(serverAddress)
+	go server.scheduler.Run(server.shutdown)
+
+	server.httpServer.Addr = serverAddress
+	if err := server.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests and waits for the running ones, then
+// sends the queued push notifications until ctx is done.
+func (server *Server) Shutdown(ctx context.Context) error {
+	err := server.httpServer.Shutdown(ctx)
+	if closeErr := server.dispatcher.Close(ctx); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 func errorResponse(err error) gin.H {
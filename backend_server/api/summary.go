@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// buildRideSummary splits the price and duration of a completed ride between
+// its passengers, proportionally to the distance each of them travelled from
+// their pickup point to the destination.
+func buildRideSummary(ride models.CreateRideResp, driverName string) models.RideSummary {
+	coordinates := utils.GeoJSONCoordinates(ride.GeoJSON)
+	total := utils.PathLength(coordinates)
+
+	// * Rides that were never started are measured from the scheduled departure
+	departedAt := ride.StartedAt
+	if departedAt == 0 {
+		departedAt = ride.Timestamp
+	}
+
+	duration := ride.CompletedAt - departedAt
+	if duration < 0 {
+		duration = 0
+	}
+
+	summary := models.RideSummary{
+		ID:          primitive.NewObjectID(),
+		RideID:      ride.Id,
+		DriverEmail: ride.Email,
+		DriverName:  driverName,
+		Origin:      ride.Origin,
+		Destination: ride.Destination,
+		Price:       ride.Price,
+		DepartedAt:  departedAt,
+		CompletedAt: ride.CompletedAt,
+		Distance:    total,
+		Duration:    duration,
+		Passengers:  []models.PassengerSummary{},
+	}
+
+	for _, passenger := range ride.Passengers {
+		// * The driver is stored as the first passenger of the ride
+		if passenger.Email == ride.Email {
+			continue
+		}
+
+		distance, share := total, 1.0
+		if index, offset := utils.NearestIndex(coordinates, passenger.OriginLat, passenger.OriginLng); index >= 0 && total > 0 {
+			distance = math.Min(offset+utils.PathLength(coordinates[index:]), total)
+			share = distance / total
+		}
+
+		summary.Passengers = append(summary.Passengers, models.PassengerSummary{
+			Email:     passenger.Email,
+			Name:      passenger.Name,
+			Origin:    passenger.Origin,
+			Distance:  distance,
+			FareShare: int(math.Round(float64(ride.Price) * share)),
+			Duration:  int64(math.Round(float64(duration) * share)),
+		})
+	}
+
+	return summary
+}
+
+func (server *Server) getRideSummary(c *gin.Context) {
+	var req models.RideSummaryReq
+	var result models.RideSummary
+
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if err := server.collection.RideSummary.FindOne(c, bson.M{"ride_id": req.RideID}).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	allowed := result.DriverEmail == authPayload.Email || authPayload.Role == token.RoleAdmin
+	for _, passenger := range result.Passengers {
+		if passenger.Email == authPayload.Email {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		err := errors.New("user was not part of this ride")
+		c.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// rateRide lets a passenger rate the driver once the ride is completed.
+// driverRatingUpdate adds the rating to the driver, keeping the running total
+// so the average is updated in place.
+func driverRatingUpdate(rating int) bson.A {
+	return bson.A{
+		bson.M{"$set": bson.M{
+			"rating_total": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_total", 0}}, rating}},
+			"rating_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_count", 0}}, 1}},
+		}},
+		bson.M{"$set": bson.M{
+			"rating": bson.M{"$divide": bson.A{"$rating_total", "$rating_count"}},
+		}},
+	}
+}
+
+func (server *Server) rateRide(c *gin.Context) {
+	var uri models.RideSummaryReq
+	var req models.RateRideReq
+
+	wc := writeconcern.New(writeconcern.WMajority())
+	rc := readconcern.Snapshot()
+	txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	session, err := server.client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer session.EndSession(c)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var summary models.RideSummary
+
+		// * Every passenger rates a ride only once
+		filter := bson.M{
+			"ride_id": uri.RideID,
+			"passengers": bson.M{
+				"$elemMatch": bson.M{"email": authPayload.Email, "rating": bson.M{"$exists": false}},
+			},
+		}
+		update := bson.M{"$set": bson.M{"passengers.$.rating": req.Rating}}
+
+		if err := server.collection.RideSummary.FindOneAndUpdate(sessCtx, filter, update).Decode(&summary); err != nil {
+			return nil, err
+		}
+
+		_, err := server.collection.Driver.UpdateOne(sessCtx, bson.M{"email": summary.DriverEmail}, driverRatingUpdate(req.Rating))
+		return nil, err
+	}
+
+	_, err = session.WithTransaction(c, callback, txnOpts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("ride not found or already rated")
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ride rated successfully"})
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMeetsMinRating(t *testing.T) {
+	unrated := models.SearchRideResult{}
+	require.True(t, meetsMinRating(unrated, 4))
+
+	rated := models.SearchRideResult{DriverRating: 3.5, DriverRatingCount: 2}
+	require.True(t, meetsMinRating(rated, 0))
+	require.True(t, meetsMinRating(rated, 3.5))
+	require.False(t, meetsMinRating(rated, 4))
+}
+
+func TestDriverRatingUpdate(t *testing.T) {
+	update := driverRatingUpdate(4)
+	require.Len(t, update, 2)
+
+	// * The average is computed from the totals updated by the first stage
+	totals := update[0].(bson.M)["$set"].(bson.M)
+	require.Contains(t, totals, "rating_total")
+	require.Contains(t, totals, "rating_count")
+	require.Equal(t, bson.M{"$divide": bson.A{"$rating_total", "$rating_count"}}, update[1].(bson.M)["$set"].(bson.M)["rating"])
+}
+
+func TestRateRideReqValidation(t *testing.T) {
+	for rating, valid := range map[int]bool{0: false, 1: true, 5: true, 6: false} {
+		err := binding.Validator.ValidateStruct(models.RateRideReq{Rating: rating})
+		require.Equal(t, valid, err == nil, rating)
+	}
+}
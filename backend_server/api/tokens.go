@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected, please login again")
+)
+
+// userRole derives the role embedded in access tokens. Admins are configured
+// by email, drivers are the users that have a document in the drivers collection.
+func (server *Server) userRole(c *gin.Context, email string) (token.Role, error) {
+	for _, adminEmail := range server.config.AdminEmails {
+		if strings.EqualFold(adminEmail, email) {
+			return token.RoleAdmin, nil
+		}
+	}
+
+	err := server.collection.Driver.FindOne(c, bson.M{"email": email}).Err()
+	if err == nil {
+		return token.RoleDriver, nil
+	}
+	if err == mongo.ErrNoDocuments {
+		return token.RolePassenger, nil
+	}
+
+	return "", err
+}
+
+func (server *Server) createAccessToken(c *gin.Context, email string, phone string, name string) (string, error) {
+	role, err := server.userRole(c, email)
+	if err != nil {
+		return "", err
+	}
+
+	return server.tokenMaker.CreateToken(email, phone, name, role, server.config.AccessTokenDuration)
+}
+
+// createSession stores a new refresh token for the given family and returns
+// the raw token, which is never persisted.
+func (server *Server) createSession(c *gin.Context, email string, familyID string) (string, models.Session, error) {
+	refreshToken, err := token.NewRefreshToken()
+	if err != nil {
+		return "", models.Session{}, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:        token.HashRefreshToken(refreshToken),
+		FamilyID:  familyID,
+		Email:     email,
+		UserAgent: c.Request.UserAgent(),
+		ClientIp:  c.ClientIP(),
+		ExpiresAt: now.Add(server.config.RefreshTokenDuration),
+		CreatedAt: now,
+	}
+
+	if _, err := server.collection.Session.InsertOne(c, session); err != nil {
+		return "", models.Session{}, err
+	}
+
+	return refreshToken, session, nil
+}
+
+func (server *Server) revokeSessionFamily(c *gin.Context, familyID string) error {
+	filter := bson.M{"family_id": familyID}
+	update := bson.M{"$set": bson.M{"is_revoked": true}}
+
+	_, err := server.collection.Session.UpdateMany(c, filter, update)
+	return err
+}
+
+func (server *Server) refreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	var session models.Session
+	var passenger models.CreatePassengerResponse
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	sessionID := token.HashRefreshToken(req.RefreshToken)
+
+	// * Mark the refresh token as used, only one caller can win this update
+	filter := bson.M{
+		"_id":        sessionID,
+		"is_used":    false,
+		"is_revoked": false,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	update := bson.M{"$set": bson.M{"is_used": true}}
+
+	err := server.collection.Session.FindOneAndUpdate(c, filter, update).Decode(&session)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if err := server.collection.Session.FindOne(c, bson.M{"_id": sessionID}).Decode(&session); err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// * A rotated token was presented again, the whole family is compromised
+		if session.IsUsed {
+			if err := server.revokeSessionFamily(c, session.FamilyID); err != nil {
+				c.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			c.JSON(http.StatusUnauthorized, errorResponse(errRefreshTokenReused))
+			return
+		}
+
+		if session.IsRevoked {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+			return
+		}
+
+		c.JSON(http.StatusUnauthorized, errorResponse(token.ErrExpiredToken))
+		return
+	}
+
+	if err := server.collection.Passenger.FindOne(c, bson.M{"email": session.Email}).Decode(&passenger); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidRefreshToken))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accessToken, err := server.createAccessToken(c, passenger.Email, passenger.Phone, passenger.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	refreshToken, newSession, err := server.createSession(c, passenger.Email, session.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resp := models.RefreshTokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  time.Now().Add(server.config.AccessTokenDuration),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newSession.ExpiresAt,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// tokenPublicKey exposes the key other services need to verify v2.public
+// tokens without being able to create them.
+func (server *Server) tokenPublicKey(c *gin.Context) {
+	maker, ok := server.tokenMaker.(token.PublicKeyMaker)
+	if !ok {
+		err := errors.New("tokens are not signed with a public key")
+		c.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":    "v2",
+		"purpose":    "public",
+		"algorithm":  "Ed25519",
+		"public_key": hex.EncodeToString(maker.PublicKey()),
+	})
+}
+
+func (server *Server) logout(c *gin.Context) {
+	var req models.LogoutRequest
+	var session models.Session
+
+	// * The refresh token is optional, an empty body only revokes the access token
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	revoked := models.RevokedToken{
+		ID:        authPayload.ID.String(),
+		Email:     authPayload.Email,
+		ExpiresAt: authPayload.ExpiredAt,
+	}
+
+	if _, err := server.collection.RevokedToken.InsertOne(c, revoked); err != nil && !mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if req.RefreshToken != "" {
+		filter := bson.M{"_id": token.HashRefreshToken(req.RefreshToken), "email": authPayload.Email}
+		err := server.collection.Session.FindOne(c, filter).Decode(&session)
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if err == nil {
+			if err := server.revokeSessionFamily(c, session.FamilyID); err != nil {
+				c.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
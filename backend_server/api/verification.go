@@ -0,0 +1,206 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/token"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	otpLength       = 6
+	otpTTL          = 10 * time.Minute
+	otpResendDelay  = time.Minute
+	otpSendWindow   = time.Hour
+	otpMaxPerWindow = 5
+	otpMaxAttempts  = 5
+)
+
+var errPhoneNotVerified = errors.New("phone number must be verified first")
+
+func randomOTP() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
+
+// isPhoneVerified reports whether the passenger confirmed the phone number
+// currently stored on the profile.
+func (server *Server) isPhoneVerified(c *gin.Context, email string) (bool, error) {
+	var passenger models.CreatePassengerResponse
+
+	if err := server.collection.Passenger.FindOne(c, bson.M{"email": email}).Decode(&passenger); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return passenger.PhoneVerified, nil
+}
+
+func (server *Server) startPhoneVerification(c *gin.Context) {
+	var passenger models.CreatePassengerResponse
+	var last models.PhoneVerification
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if err := server.collection.Passenger.FindOne(c, bson.M{"email": authPayload.Email}).Decode(&passenger); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if passenger.PhoneVerified {
+		c.JSON(http.StatusOK, gin.H{"message": "phone number already verified"})
+		return
+	}
+
+	// * Rate limit, one code per minute and a few codes per hour
+	now := time.Now()
+	filter := bson.M{"email": authPayload.Email, "created_at": bson.M{"$gt": now.Add(-otpSendWindow)}}
+
+	sent, err := server.collection.PhoneVerification.CountDocuments(c, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err = server.collection.PhoneVerification.FindOne(c, filter, opts).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if sent >= otpMaxPerWindow || (err == nil && now.Sub(last.CreatedAt) < otpResendDelay) {
+		err := errors.New("too many verification codes requested, try again later")
+		c.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return
+	}
+
+	code, err := randomOTP()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// * Only the hash of the code is stored
+	codeHash, err := utils.HashedPassword(code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	verification := models.PhoneVerification{
+		Email:     authPayload.Email,
+		Phone:     passenger.Phone,
+		CodeHash:  codeHash,
+		ExpiresAt: now.Add(otpTTL),
+		CreatedAt: now,
+	}
+
+	if _, err := server.collection.PhoneVerification.InsertOne(c, verification); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	msg := fmt.Sprintf("Your car pooling verification code is %s. It expires in %d minutes.", code, int(otpTTL.Minutes()))
+	if err := server.smsSender.Send(c, passenger.Phone, msg); err != nil {
+		c.JSON(http.StatusBadGateway, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification code sent", "expires_at": verification.ExpiresAt})
+}
+
+func (server *Server) confirmPhoneVerification(c *gin.Context) {
+	var req models.ConfirmPhoneVerificationReq
+	var verification models.PhoneVerification
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := c.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// * Only the latest code is valid
+	filter := bson.M{"email": authPayload.Email, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+
+	if err := server.collection.PhoneVerification.FindOne(c, filter, opts).Decode(&verification); err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("no pending verification code, request a new one")
+			c.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// * Every attempt is counted before the code is checked, so concurrent
+	// * guesses cannot go past the limit
+	filter2 := bson.M{"_id": verification.ID, "attempts": bson.M{"$lt": otpMaxAttempts}}
+	update := bson.M{"$inc": bson.M{"attempts": 1}}
+
+	if err := server.collection.PhoneVerification.FindOneAndUpdate(c, filter2, update).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			err := errors.New("too many wrong attempts, request a new code")
+			c.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := utils.CheckPassword(req.Code, verification.CodeHash); err != nil {
+		err := errors.New("invalid verification code")
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// * The phone may have changed since the code was sent
+	passengerFilter := bson.M{"email": authPayload.Email, "phone": verification.Phone}
+	update2 := bson.M{"$set": bson.M{"phone_verified": true}}
+
+	result, err := server.collection.Passenger.UpdateOne(c, passengerFilter, update2)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		err := errors.New("phone number changed, request a new code")
+		c.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	if _, err := server.collection.PhoneVerification.DeleteMany(c, bson.M{"email": authPayload.Email}); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "phone number verified successfully"})
+}
@@ -0,0 +1,127 @@
+package firebase
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	googleCertsURL      = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+	defaultKeysLifetime = time.Hour
+)
+
+var maxAgeRegex = regexp.MustCompile(`max-age=(\d+)`)
+
+// KeySource returns the public keys ID tokens are signed with, by key id.
+type KeySource interface {
+	Keys(ctx context.Context) (map[string]*rsa.PublicKey, error)
+}
+
+// StaticKeySource is a fixed key set, used by tests and local development.
+type StaticKeySource map[string]*rsa.PublicKey
+
+func (source StaticKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	return source, nil
+}
+
+// HTTPKeySource downloads Google's public certificates and caches them for as
+// long as the Cache-Control header allows.
+type HTTPKeySource struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+}
+
+func NewHTTPKeySource() *HTTPKeySource {
+	return &HTTPKeySource{
+		url:    googleCertsURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (source *HTTPKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	if source.keys != nil && time.Now().Before(source.expiresAt) {
+		return source.keys, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get firebase public keys : %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot get firebase public keys : status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read response body : %v", err)
+	}
+
+	keys, err := parseCertificates(body)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := defaultKeysLifetime
+	if match := maxAgeRegex.FindStringSubmatch(resp.Header.Get("Cache-Control")); match != nil {
+		if seconds, err := strconv.Atoi(match[1]); err == nil {
+			lifetime = time.Duration(seconds) * time.Second
+		}
+	}
+
+	source.keys = keys
+	source.expiresAt = time.Now().Add(lifetime)
+
+	return keys, nil
+}
+
+func parseCertificates(body []byte) (map[string]*rsa.PublicKey, error) {
+	var certs map[string]string
+	if err := json.Unmarshal(body, &certs); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal response body : %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, cert := range certs {
+		block, _ := pem.Decode([]byte(cert))
+		if block == nil {
+			return nil, fmt.Errorf("cannot decode certificate %s", kid)
+		}
+
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse certificate %s : %v", kid, err)
+		}
+
+		publicKey, ok := parsed.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("certificate %s is not a RSA key", kid)
+		}
+		keys[kid] = publicKey
+	}
+
+	return keys, nil
+}
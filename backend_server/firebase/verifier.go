@@ -0,0 +1,92 @@
+package firebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var ErrInvalidIDToken = errors.New("invalid firebase id token")
+
+// Token is the verified identity carried by a Firebase ID token.
+type Token struct {
+	UID           string
+	Email         string
+	EmailVerified bool
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Verifier checks Firebase ID tokens issued for a single project.
+type Verifier struct {
+	projectID string
+	keys      KeySource
+}
+
+func NewVerifier(projectID string, keys KeySource) (*Verifier, error) {
+	if projectID == "" {
+		return nil, errors.New("firebase project id is empty")
+	}
+
+	verifier := &Verifier{
+		projectID: projectID,
+		keys:      keys,
+	}
+
+	return verifier, nil
+}
+
+func (verifier *Verifier) Verify(ctx context.Context, idToken string) (*Token, error) {
+	keys, err := verifier.keys.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFunc := func(jwtToken *jwt.Token) (interface{}, error) {
+		kid, ok := jwtToken.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidIDToken
+		}
+
+		key, ok := keys[kid]
+		if !ok {
+			return nil, ErrInvalidIDToken
+		}
+
+		return key, nil
+	}
+
+	var tokenClaims claims
+	_, err = jwt.ParseWithClaims(idToken, &tokenClaims, keyFunc, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidIDToken, err)
+	}
+
+	issuer := "https://securetoken.google.com/" + verifier.projectID
+	if !tokenClaims.VerifyIssuer(issuer, true) || !tokenClaims.VerifyAudience(verifier.projectID, true) {
+		return nil, fmt.Errorf("%w : token was not issued for project %s", ErrInvalidIDToken, verifier.projectID)
+	}
+
+	if tokenClaims.Subject == "" {
+		return nil, fmt.Errorf("%w : token has no subject", ErrInvalidIDToken)
+	}
+
+	// * Roles are given by email, an unverified email could be anyone's
+	if tokenClaims.Email == "" || !tokenClaims.EmailVerified {
+		return nil, fmt.Errorf("%w : email is not verified", ErrInvalidIDToken)
+	}
+
+	token := &Token{
+		UID:           tokenClaims.Subject,
+		Email:         tokenClaims.Email,
+		EmailVerified: tokenClaims.EmailVerified,
+	}
+
+	return token, nil
+}
@@ -0,0 +1,107 @@
+package firebase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const testProjectID = "car-pooling-test"
+
+func newTestVerifier(t *testing.T) (*Verifier, *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewVerifier(testProjectID, StaticKeySource{"key-1": &privateKey.PublicKey})
+	require.NoError(t, err)
+
+	return verifier, privateKey
+}
+
+func signIDToken(t *testing.T, privateKey *rsa.PrivateKey, kid string, tokenClaims claims) string {
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, tokenClaims)
+	jwtToken.Header["kid"] = kid
+
+	idToken, err := jwtToken.SignedString(privateKey)
+	require.NoError(t, err)
+
+	return idToken
+}
+
+func validClaims(uid string, email string) claims {
+	now := time.Now()
+
+	return claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://securetoken.google.com/" + testProjectID,
+			Audience:  jwt.ClaimStrings{testProjectID},
+			Subject:   uid,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Email:         email,
+		EmailVerified: true,
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	verifier, privateKey := newTestVerifier(t)
+
+	uid := utils.RandomString(28)
+	email := utils.RandomEmail()
+
+	token, err := verifier.Verify(context.Background(), signIDToken(t, privateKey, "key-1", validClaims(uid, email)))
+	require.NoError(t, err)
+	require.Equal(t, uid, token.UID)
+	require.Equal(t, email, token.Email)
+	require.True(t, token.EmailVerified)
+}
+
+func TestVerifierRejectsInvalidTokens(t *testing.T) {
+	verifier, privateKey := newTestVerifier(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	expired := validClaims(utils.RandomString(28), utils.RandomEmail())
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	otherProject := validClaims(utils.RandomString(28), utils.RandomEmail())
+	otherProject.Audience = jwt.ClaimStrings{"other-project"}
+
+	otherIssuer := validClaims(utils.RandomString(28), utils.RandomEmail())
+	otherIssuer.Issuer = "https://securetoken.google.com/other-project"
+
+	noSubject := validClaims("", utils.RandomEmail())
+
+	unverifiedEmail := validClaims(utils.RandomString(28), utils.RandomEmail())
+	unverifiedEmail.EmailVerified = false
+
+	noEmail := validClaims(utils.RandomString(28), "")
+
+	testCases := map[string]string{
+		"Expired":         signIDToken(t, privateKey, "key-1", expired),
+		"OtherProject":    signIDToken(t, privateKey, "key-1", otherProject),
+		"OtherIssuer":     signIDToken(t, privateKey, "key-1", otherIssuer),
+		"NoSubject":       signIDToken(t, privateKey, "key-1", noSubject),
+		"UnverifiedEmail": signIDToken(t, privateKey, "key-1", unverifiedEmail),
+		"NoEmail":         signIDToken(t, privateKey, "key-1", noEmail),
+		"UnknownKey":      signIDToken(t, privateKey, "key-2", validClaims(utils.RandomString(28), utils.RandomEmail())),
+		"WrongKey":        signIDToken(t, otherKey, "key-1", validClaims(utils.RandomString(28), utils.RandomEmail())),
+		"Malformed":       utils.RandomString(32),
+	}
+
+	for name, idToken := range testCases {
+		t.Run(name, func(t *testing.T) {
+			token, err := verifier.Verify(context.Background(), idToken)
+			require.ErrorIs(t, err, ErrInvalidIDToken)
+			require.Nil(t, token)
+		})
+	}
+}
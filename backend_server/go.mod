@@ -3,6 +3,8 @@ module github.com/achintya-7/car_pooling_backend
 go 1.19
 
 require (
+	github.com/gin-contrib/sse v0.1.0
+	github.com/golang-jwt/jwt/v4 v4.4.3
 	github.com/spf13/viper v1.14.0
 	golang.org/x/crypto v0.0.0-20220622213112-05595931fe9d
 )
@@ -12,7 +14,6 @@ require (
 	github.com/aead/chacha20poly1305 v0.0.0-20170617001512-233f39982aeb // indirect
 	github.com/aead/poly1305 v0.0.0-20180717145839-3fee0db0b635 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/gin-contrib/sse v0.1.0 // indirect
 	github.com/go-playground/locales v0.14.0 // indirect
 	github.com/go-playground/universal-translator v0.18.0 // indirect
 	github.com/go-playground/validator/v10 v10.11.1 // indirect
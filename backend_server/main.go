@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/achintya-7/car_pooling_backend/api"
 	"github.com/achintya-7/car_pooling_backend/utils"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	config, err := utils.LoadConfig(".")
 	if err != nil {
@@ -20,9 +27,21 @@ func main() {
 		log.Fatal("cannot create a server", err)
 	}
 
-	err = server.Start(config.ServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server", err)
-	}
+	go func() {
+		if err := server.Start(config.ServerAddress); err != nil {
+			log.Fatal("cannot start server", err)
+		}
+	}()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	// * Running requests finish and queued push notifications are sent
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("cannot shut down server", err)
+	}
 }
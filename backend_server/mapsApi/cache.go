@@ -0,0 +1,328 @@
+package mapsApi
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultCacheSize      = 1000
+	DefaultPredictionsTTL = 24 * time.Hour
+	DefaultPlaceTTL       = 30 * 24 * time.Hour // places hardly ever move
+	DefaultRouteTTL       = 24 * time.Hour
+)
+
+// * Kinds of cached calls, also the keys of the cache stats
+const (
+	cachePredictions = "predictions"
+	cacheDetails     = "details"
+	cacheRoute       = "route"
+	cacheCoordinates = "coordinates"
+)
+
+// CacheStore keeps encoded responses until their ttl is over. Get returns the
+// remaining ttl so that copies made elsewhere expire at the same time.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (value []byte, ttl time.Duration, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type CacheConfig struct {
+	// * Prefixes the keys, place ids of a provider mean nothing to another
+	Provider       string
+	Size           int // responses kept in memory
+	PredictionsTTL time.Duration
+	PlaceTTL       time.Duration // place details and coordinates
+	RouteTTL       time.Duration
+}
+
+func (config CacheConfig) withDefaults() CacheConfig {
+	if config.Size == 0 {
+		config.Size = DefaultCacheSize
+	}
+	if config.PredictionsTTL == 0 {
+		config.PredictionsTTL = DefaultPredictionsTTL
+	}
+	if config.PlaceTTL == 0 {
+		config.PlaceTTL = DefaultPlaceTTL
+	}
+	if config.RouteTTL == 0 {
+		config.RouteTTL = DefaultRouteTTL
+	}
+	return config
+}
+
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+type cacheCounters struct {
+	hits   uint64
+	misses uint64
+}
+
+type bypassCacheKey struct{}
+
+// WithoutCache makes the calls made with ctx skip the cache, their responses
+// still replace the cached ones.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// Cache answers repeated calls from an in memory LRU, then from the
+// additional stores, before calling the provider. Errors are never cached.
+type Cache struct {
+	MapsProvider
+
+	config   CacheConfig
+	stores   []CacheStore
+	counters map[string]*cacheCounters
+}
+
+func NewCache(provider MapsProvider, config CacheConfig, stores ...CacheStore) *Cache {
+	config = config.withDefaults()
+
+	return &Cache{
+		MapsProvider: provider,
+		config:       config,
+		stores:       append([]CacheStore{NewMemoryStore(config.Size)}, stores...),
+		counters: map[string]*cacheCounters{
+			cachePredictions: {},
+			cacheDetails:     {},
+			cacheRoute:       {},
+			cacheCoordinates: {},
+		},
+	}
+}
+
+// Stats returns the hits and misses of each kind of call.
+func (cache *Cache) Stats() map[string]CacheStats {
+	stats := make(map[string]CacheStats, len(cache.counters))
+	for kind, counters := range cache.counters {
+		stats[kind] = CacheStats{
+			Hits:   atomic.LoadUint64(&counters.hits),
+			Misses: atomic.LoadUint64(&counters.misses),
+		}
+	}
+	return stats
+}
+
+func (cache *Cache) Degraded() bool {
+	if reporter, ok := cache.MapsProvider.(HealthReporter); ok {
+		return reporter.Degraded()
+	}
+	return false
+}
+
+// get decodes the cached response into value, copying it into the faster
+// stores that missed it. Calls closing an autocomplete session always reach
+// the provider, the session being billed per prediction otherwise.
+func (cache *Cache) get(ctx context.Context, kind, key string, value interface{}) bool {
+	if cacheBypassed(ctx) || sessionToken(ctx) != "" {
+		return false
+	}
+
+	for i, store := range cache.stores {
+		data, ttl, ok, err := store.Get(ctx, cache.key(kind, key))
+		if err != nil {
+			log.Printf("cannot read maps cache : %v", err)
+			continue
+		}
+		if !ok || json.Unmarshal(data, value) != nil {
+			continue
+		}
+
+		for _, faster := range cache.stores[:i] {
+			faster.Set(ctx, cache.key(kind, key), data, ttl)
+		}
+
+		atomic.AddUint64(&cache.counters[kind].hits, 1)
+		return true
+	}
+
+	atomic.AddUint64(&cache.counters[kind].misses, 1)
+	return false
+}
+
+func (cache *Cache) set(ctx context.Context, kind, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	for _, store := range cache.stores {
+		if err := store.Set(ctx, cache.key(kind, key), data, cache.ttl(kind)); err != nil {
+			log.Printf("cannot write maps cache : %v", err)
+		}
+	}
+}
+
+func (cache *Cache) key(kind, key string) string {
+	return cache.config.Provider + ":" + kind + ":" + key
+}
+
+func (cache *Cache) ttl(kind string) time.Duration {
+	switch kind {
+	case cachePredictions:
+		return cache.config.PredictionsTTL
+	case cacheRoute:
+		return cache.config.RouteTTL
+	default:
+		return cache.config.PlaceTTL
+	}
+}
+
+func (cache *Cache) PlacePredictions(ctx context.Context, input string, options PredictionOptions) ([]Predictions, error) {
+	var predictions []Predictions
+
+	// * Every keystroke is searched, the case and surrounding spaces do not matter
+	key := strings.ToLower(strings.TrimSpace(input)) + "|" + options.cacheKey()
+	if cache.get(ctx, cachePredictions, key, &predictions) {
+		return predictions, nil
+	}
+
+	predictions, err := cache.MapsProvider.PlacePredictions(ctx, input, options)
+	if err != nil {
+		return predictions, err
+	}
+
+	cache.set(ctx, cachePredictions, key, predictions)
+	return predictions, nil
+}
+
+func (cache *Cache) PlaceDetails(ctx context.Context, placeId string) (Place, error) {
+	var place Place
+
+	if cache.get(ctx, cacheDetails, placeId, &place) {
+		return place, nil
+	}
+
+	place, err := cache.MapsProvider.PlaceDetails(ctx, placeId)
+	if err != nil {
+		return place, err
+	}
+
+	cache.set(ctx, cacheDetails, placeId, place)
+	return place, nil
+}
+
+func (cache *Cache) Route(ctx context.Context, origin, destination string) (Route, error) {
+	var route Route
+
+	key := origin + "|" + destination
+	if cache.get(ctx, cacheRoute, key, &route) {
+		return route, nil
+	}
+
+	route, err := cache.MapsProvider.Route(ctx, origin, destination)
+	if err != nil {
+		return route, err
+	}
+
+	cache.set(ctx, cacheRoute, key, route)
+	return route, nil
+}
+
+func (cache *Cache) Coordinates(ctx context.Context, placeId string) (Point, error) {
+	var point Point
+
+	if cache.get(ctx, cacheCoordinates, placeId, &point) {
+		return point, nil
+	}
+
+	point, err := cache.MapsProvider.Coordinates(ctx, placeId)
+	if err != nil {
+		return point, err
+	}
+
+	cache.set(ctx, cacheCoordinates, placeId, point)
+	return point, nil
+}
+
+// MemoryStore keeps the most recently used responses in memory.
+type MemoryStore struct {
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	recent  *list.List // front is the most recently used
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{
+		size:    size,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+func (store *MemoryStore) Get(ctx context.Context, key string) ([]byte, time.Duration, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	element, ok := store.entries[key]
+	if !ok {
+		return nil, 0, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	ttl := entry.expiresAt.Sub(store.now())
+	if ttl <= 0 {
+		store.recent.Remove(element)
+		delete(store.entries, key)
+		return nil, 0, false, nil
+	}
+
+	store.recent.MoveToFront(element)
+	return entry.value, ttl, true, nil
+}
+
+func (store *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value, expiresAt: store.now().Add(ttl)}
+
+	if element, ok := store.entries[key]; ok {
+		element.Value = entry
+		store.recent.MoveToFront(element)
+		return nil
+	}
+
+	store.entries[key] = store.recent.PushFront(entry)
+
+	for store.recent.Len() > store.size {
+		oldest := store.recent.Back()
+		store.recent.Remove(oldest)
+		delete(store.entries, oldest.Value.(*memoryEntry).key)
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.recent.Len()
+}
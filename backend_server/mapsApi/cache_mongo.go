@@ -0,0 +1,55 @@
+package mapsApi
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps responses across restarts, expired documents are removed
+// by the ttl index on expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+type mongoCacheEntry struct {
+	Key       string    `bson:"_id"`
+	Value     []byte    `bson:"value"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (store *MongoStore) Get(ctx context.Context, key string) ([]byte, time.Duration, bool, error) {
+	var entry mongoCacheEntry
+
+	// * The ttl monitor only runs every minute
+	filter := bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}
+
+	err := store.collection.FindOne(ctx, filter).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return nil, 0, false, nil
+	}
+
+	return entry.Value, ttl, true, nil
+}
+
+func (store *MongoStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	update := bson.M{"$set": bson.M{"value": value, "expires_at": time.Now().Add(ttl)}}
+
+	_, err := store.collection.UpdateByID(ctx, key, update, options.Update().SetUpsert(true))
+	return err
+}
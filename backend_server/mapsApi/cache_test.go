@@ -0,0 +1,168 @@
+package mapsApi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheCoordinates(t *testing.T) {
+	provider := NewFakeProvider()
+	cache := NewCache(provider, CacheConfig{})
+
+	for i := 0; i < 3; i++ {
+		point, err := cache.Coordinates(context.Background(), "fake-pari-chowk")
+		require.NoError(t, err)
+		require.Equal(t, Point{Lat: 28.4655, Lng: 77.5100}, point)
+	}
+	require.Equal(t, 1, provider.Calls("Coordinates"))
+
+	// * Failures are not cached
+	for i := 0; i < 2; i++ {
+		_, err := cache.Coordinates(context.Background(), "nowhere")
+		require.ErrorIs(t, err, ErrInvalidPlace)
+	}
+	require.Equal(t, 3, provider.Calls("Coordinates"))
+
+	require.Equal(t, CacheStats{Hits: 2, Misses: 3}, cache.Stats()[cacheCoordinates])
+}
+
+func TestCacheSessionReachesProvider(t *testing.T) {
+	provider := NewFakeProvider()
+	cache := NewCache(provider, CacheConfig{})
+
+	_, err := cache.Coordinates(context.Background(), "fake-pari-chowk")
+	require.NoError(t, err)
+
+	// * Closing an autocomplete session needs the provider call
+	point, err := cache.Coordinates(WithSessionToken(context.Background(), "session"), "fake-pari-chowk")
+	require.NoError(t, err)
+	require.Equal(t, Point{Lat: 28.4655, Lng: 77.5100}, point)
+	require.Equal(t, 2, provider.Calls("Coordinates"))
+}
+
+func TestCachePredictionsIgnoreCase(t *testing.T) {
+	provider := NewFakeProvider()
+	cache := NewCache(provider, CacheConfig{})
+
+	first, err := cache.PlacePredictions(context.Background(), "Noida", PredictionOptions{})
+	require.NoError(t, err)
+
+	second, err := cache.PlacePredictions(context.Background(), " noida ", PredictionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, 1, provider.Calls("PlacePredictions"))
+
+	// * Biased elsewhere the predictions may differ, the session token does not matter
+	near := PredictionOptions{Bias: LocationBias{Center: &Point{Lat: 28.6315, Lng: 77.2167}, Radius: 20000}}
+	_, err = cache.PlacePredictions(context.Background(), "noida", near)
+	require.NoError(t, err)
+	require.Equal(t, 2, provider.Calls("PlacePredictions"))
+
+	near.SessionToken = "session"
+	near.Bias.Center = &Point{Lat: 28.6312, Lng: 77.2171}
+	_, err = cache.PlacePredictions(context.Background(), "noida", near)
+	require.NoError(t, err)
+	require.Equal(t, 2, provider.Calls("PlacePredictions"))
+}
+
+func TestCacheRouteBypass(t *testing.T) {
+	provider := NewFakeProvider()
+	cache := NewCache(provider, CacheConfig{})
+
+	route, err := cache.Route(context.Background(), "fake-amity-university", "fake-sector-18")
+	require.NoError(t, err)
+
+	// * A bypassed call refreshes the cached route
+	provider.AddRoute("fake-amity-university", "fake-sector-18", Route{Points: []Point{{Lat: 1, Lng: 2}}})
+	fresh, err := cache.Route(WithoutCache(context.Background()), "fake-amity-university", "fake-sector-18")
+	require.NoError(t, err)
+	require.NotEqual(t, route, fresh)
+
+	cached, err := cache.Route(context.Background(), "fake-amity-university", "fake-sector-18")
+	require.NoError(t, err)
+	require.Equal(t, fresh, cached)
+	require.Equal(t, 2, provider.Calls("Route"))
+	require.Equal(t, CacheStats{Hits: 1, Misses: 1}, cache.Stats()[cacheRoute])
+}
+
+func TestCacheSecondStore(t *testing.T) {
+	provider := NewFakeProvider()
+	persistent := NewMemoryStore(10)
+
+	point, err := NewCache(provider, CacheConfig{}, persistent).Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+
+	// * A new cache, like after a restart, reads the persistent store
+	restarted := NewCache(provider, CacheConfig{}, persistent)
+	cached, err := restarted.Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+	require.Equal(t, point, cached)
+	require.Equal(t, 1, provider.Calls("Coordinates"))
+}
+
+func TestCacheSecondStoreKeepsTTL(t *testing.T) {
+	provider := NewFakeProvider()
+	persistent := NewMemoryStore(10)
+
+	now := time.Now()
+	persistent.now = func() time.Time { return now }
+
+	_, err := NewCache(provider, CacheConfig{PlaceTTL: time.Hour}, persistent).Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+
+	// * Copied into memory with what is left of the ttl, not a full one
+	now = now.Add(40 * time.Minute)
+	restarted := NewCache(provider, CacheConfig{PlaceTTL: time.Hour}, persistent)
+	_, err = restarted.Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+
+	_, ttl, ok, err := restarted.stores[0].Get(context.Background(), restarted.key(cacheCoordinates, "fake-sector-18"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.LessOrEqual(t, ttl, 20*time.Minute)
+}
+
+func TestCacheKeysPerProvider(t *testing.T) {
+	provider := NewFakeProvider()
+	persistent := NewMemoryStore(10)
+
+	_, err := NewCache(provider, CacheConfig{Provider: "google"}, persistent).Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+
+	// * Switching providers does not serve the other provider's places
+	_, err = NewCache(provider, CacheConfig{Provider: "osm"}, persistent).Coordinates(context.Background(), "fake-sector-18")
+	require.NoError(t, err)
+	require.Equal(t, 2, provider.Calls("Coordinates"))
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	store := NewMemoryStore(2)
+	ctx := context.Background()
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// * Reading a makes b the least recently used
+	_, _, ok, _ := store.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, store.Set(ctx, "c", []byte("3"), time.Second))
+
+	_, _, ok, _ = store.Get(ctx, "b")
+	require.False(t, ok)
+	require.Equal(t, 2, store.Len())
+
+	now = now.Add(time.Second)
+	_, _, ok, _ = store.Get(ctx, "c")
+	require.False(t, ok)
+
+	value, ttl, ok, _ := store.Get(ctx, "a")
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), value)
+	require.Equal(t, time.Minute-time.Second, ttl)
+}
@@ -0,0 +1,234 @@
+package mapsApi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout          = 5 * time.Second
+	DefaultMaxRetries       = 2
+	DefaultBackoff          = 200 * time.Millisecond
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrUnavailable is returned without calling the api while the circuit
+// breaker is open.
+var ErrUnavailable = errors.New("maps api unavailable")
+
+type ClientConfig struct {
+	Timeout    time.Duration // per attempt
+	MaxRetries int           // negative disables retries, zero uses the default
+	Backoff    time.Duration // doubled on every retry, with jitter
+	// * Consecutive failed calls opening the breaker, and how long it stays open
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// withDefaults fills in the zero fields.
+func (config ClientConfig) withDefaults() ClientConfig {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.Backoff == 0 {
+		config.Backoff = DefaultBackoff
+	}
+	if config.BreakerThreshold == 0 {
+		config.BreakerThreshold = DefaultBreakerThreshold
+	}
+	if config.BreakerCooldown == 0 {
+		config.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return config
+}
+
+// Client calls one maps api, retrying failed calls and failing fast once the
+// api keeps failing. Only GET requests are made so every call is retried.
+type Client struct {
+	config ClientConfig
+	http   *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewClient(config ClientConfig) *Client {
+	config = config.withDefaults()
+
+	return &Client{
+		config: config,
+		http:   &http.Client{Timeout: config.Timeout},
+		now:    time.Now,
+	}
+}
+
+// Degraded reports whether the breaker is open, the api having failed
+// BreakerThreshold times in a row.
+func (client *Client) Degraded() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	return client.failures >= client.config.BreakerThreshold
+}
+
+// allow reports whether a call can be made. Once the cooldown is over a
+// single call probes the api, closing the breaker if it succeeds.
+func (client *Client) allow() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.failures < client.config.BreakerThreshold {
+		return true
+	}
+	if client.probing || client.now().Before(client.openUntil) {
+		return false
+	}
+
+	client.probing = true
+	return true
+}
+
+func (client *Client) record(failed bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.probing = false
+	if !failed {
+		client.failures = 0
+		return
+	}
+
+	client.failures++
+	if client.failures >= client.config.BreakerThreshold {
+		client.openUntil = client.now().Add(client.config.BreakerCooldown)
+	}
+}
+
+// abandon lets another call probe the api when the probe was cancelled.
+func (client *Client) abandon() {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	client.probing = false
+}
+
+// transientStatus is implemented by responses reporting in the body that the
+// api failed even though the status code is 2xx.
+type transientStatus interface {
+	transient() bool
+}
+
+// retryable reports whether the attempt failed because of the api rather
+// than the request, code being zero when no response was read.
+func retryable(code int, result interface{}) bool {
+	if code == 0 || code == http.StatusTooManyRequests || code >= 500 {
+		return true
+	}
+	if status, ok := result.(transientStatus); ok && code < 300 {
+		return status.transient()
+	}
+	return false
+}
+
+// backoff waits before the retry, giving up when ctx is done.
+func (client *Client) backoff(ctx context.Context, retry int) error {
+	wait := client.config.Backoff << retry
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attempt sends the request once and decodes the body into result. The body
+// of non 2xx responses is still decoded when possible as some apis explain
+// the error in it.
+func (client *Client) attempt(ctx context.Context, request *http.Request, result interface{}) (int, error) {
+	resp, err := client.http.Do(request.Clone(ctx))
+	if err != nil {
+		// * Only keep the cause, the url carries the api key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("cannot call %s : %w", request.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read response body : %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		json.Unmarshal(body, result)
+		return resp.StatusCode, fmt.Errorf("cannot call %s : %w", request.URL.Path, statusError(resp.StatusCode))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return resp.StatusCode, fmt.Errorf("cannot unmarshal response body : %v", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// getJSON calls rawURL and decodes the json body into result, retrying while
+// the call fails with a retryable error.
+func (client *Client) getJSON(ctx context.Context, rawURL string, header http.Header, result interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		// * The url carries the api key
+		return errors.New("invalid request url")
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+
+	if !client.allow() {
+		return ErrUnavailable
+	}
+
+	for retry := 0; ; retry++ {
+		code, err := client.attempt(ctx, request, result)
+		if ctx.Err() != nil {
+			// * The caller gave up, this says nothing about the api
+			client.abandon()
+			return ctx.Err()
+		}
+
+		failed := retryable(code, result)
+		if !failed || retry == client.config.MaxRetries {
+			client.record(failed)
+			return err
+		}
+
+		if err := client.backoff(ctx, retry); err != nil {
+			client.abandon()
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package mapsApi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer fails the first failures calls with code then answers ok.
+func newFlakyServer(t *testing.T, failures int32, code int) (*httptest.Server, *int32) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(code)
+			return
+		}
+		fmt.Fprint(w, `{"status":"OK"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestClientRetries(t *testing.T) {
+	server, calls := newFlakyServer(t, 2, http.StatusServiceUnavailable)
+	client := NewClient(ClientConfig{Backoff: time.Millisecond})
+
+	var status GoogleStatus
+	require.NoError(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.Equal(t, "OK", status.Status)
+	require.EqualValues(t, 3, atomic.LoadInt32(calls))
+	require.False(t, client.Degraded())
+}
+
+func TestClientRetriesDisabled(t *testing.T) {
+	server, calls := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+	client := NewClient(ClientConfig{MaxRetries: -1, Backoff: time.Millisecond})
+
+	var status GoogleStatus
+	require.Error(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestClientRetriesGoogleStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			fmt.Fprint(w, `{"status":"UNKNOWN_ERROR"}`)
+		case 2:
+			fmt.Fprint(w, `{"status":"OVER_QUERY_LIMIT"}`)
+		default:
+			fmt.Fprint(w, `{"status":"OK"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// * Failures reported with a 200 are retried like server errors
+	client := NewClient(ClientConfig{Backoff: time.Millisecond})
+
+	var status GoogleStatus
+	require.NoError(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.Equal(t, "OK", status.Status)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// * And they open the breaker once retries run out
+	client = NewClient(ClientConfig{MaxRetries: -1, BreakerThreshold: 1})
+	atomic.StoreInt32(&calls, 0)
+
+	require.NoError(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.Equal(t, "UNKNOWN_ERROR", status.Status)
+	require.True(t, client.Degraded())
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	server, calls := newFlakyServer(t, 1, http.StatusBadRequest)
+	client := NewClient(ClientConfig{Backoff: time.Millisecond})
+
+	var status GoogleStatus
+	require.Error(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	server, calls := newFlakyServer(t, 4, http.StatusInternalServerError)
+	client := NewClient(ClientConfig{MaxRetries: 1, Backoff: time.Millisecond, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	var status GoogleStatus
+	for i := 0; i < 2; i++ {
+		require.Error(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	}
+	require.True(t, client.Degraded())
+	require.EqualValues(t, 4, atomic.LoadInt32(calls))
+
+	// * Open, the api is not called
+	err := client.getJSON(context.Background(), server.URL, nil, &status)
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.EqualValues(t, 4, atomic.LoadInt32(calls))
+
+	// * After the cooldown a probe succeeds and closes the breaker
+	now = now.Add(time.Minute)
+	require.NoError(t, client.getJSON(context.Background(), server.URL, nil, &status))
+	require.False(t, client.Degraded())
+}
+
+func TestClientCancelledCallsDoNotTrip(t *testing.T) {
+	server, _ := newFlakyServer(t, 100, http.StatusInternalServerError)
+	client := NewClient(ClientConfig{Backoff: time.Minute, BreakerThreshold: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var status GoogleStatus
+	err := client.getJSON(ctx, server.URL, nil, &status)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, client.Degraded())
+}
+
+func TestClientErrorsHideTheKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(ClientConfig{Timeout: 10 * time.Millisecond, Backoff: time.Millisecond})
+
+	var status GoogleStatus
+	err := client.getJSON(context.Background(), server.URL+"/geocode/json?key=secret-key", nil, &status)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret-key")
+}
@@ -0,0 +1,60 @@
+package mapsApi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrNoRoute       = errors.New("no route found between origin and destination")
+	ErrQuotaExceeded = errors.New("maps api quota exceeded")
+	ErrDenied        = errors.New("maps api request denied")
+	ErrInvalidPlace  = errors.New("invalid place")
+)
+
+// statusError maps a non 2xx response of any maps api to an error.
+func statusError(code int) error {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrDenied
+	case code == http.StatusNotFound:
+		return ErrInvalidPlace
+	default:
+		return fmt.Errorf("unexpected status %d", code)
+	}
+}
+
+// transient reports whether Google failed to answer, the call being worth
+// retrying and counting against the circuit breaker.
+func (status GoogleStatus) transient() bool {
+	return status.Status == "OVER_QUERY_LIMIT" || status.Status == "UNKNOWN_ERROR"
+}
+
+// err maps the status of a Google response to an error, zeroResults being
+// returned for ZERO_RESULTS as it is not an error for every api.
+func (status GoogleStatus) err(zeroResults error) error {
+	var err error
+
+	switch status.Status {
+	case "OK":
+		return nil
+	case "ZERO_RESULTS":
+		return zeroResults
+	case "NOT_FOUND", "INVALID_REQUEST":
+		err = ErrInvalidPlace
+	case "OVER_QUERY_LIMIT", "OVER_DAILY_LIMIT":
+		err = ErrQuotaExceeded
+	case "REQUEST_DENIED":
+		err = ErrDenied
+	default:
+		err = fmt.Errorf("unexpected status %q", status.Status)
+	}
+
+	if status.ErrorMessage != "" {
+		return fmt.Errorf("%w : %s", err, status.ErrorMessage)
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package mapsApi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fakeRouteSteps is the number of points of the straight routes built by the fake.
+const fakeRouteSteps = 10
+
+type FakePlace struct {
+	PlaceId     string
+	Description string
+	Point       Point
+}
+
+// FakeProvider is a deterministic in-memory provider for tests and offline
+// development. Routes between known places are straight lines unless one was
+// added explicitly.
+type FakeProvider struct {
+	mu     sync.RWMutex
+	places map[string]FakePlace
+	routes map[string]Route
+	calls  map[string]int
+}
+
+// NewFakeProvider returns a provider knowing a few places around the campus.
+func NewFakeProvider() *FakeProvider {
+	provider := &FakeProvider{
+		places: make(map[string]FakePlace),
+		routes: make(map[string]Route),
+		calls:  make(map[string]int),
+	}
+
+	for _, place := range []FakePlace{
+		{PlaceId: "fake-amity-university", Description: "Amity University, Sector 125, Noida", Point: Point{Lat: 28.5440, Lng: 77.3331}},
+		{PlaceId: "fake-botanical-garden", Description: "Botanical Garden Metro Station, Sector 38, Noida", Point: Point{Lat: 28.5641, Lng: 77.3341}},
+		{PlaceId: "fake-sector-18", Description: "Sector 18 Market, Noida", Point: Point{Lat: 28.5708, Lng: 77.3261}},
+		{PlaceId: "fake-pari-chowk", Description: "Pari Chowk, Greater Noida", Point: Point{Lat: 28.4655, Lng: 77.5100}},
+		{PlaceId: "fake-connaught-place", Description: "Connaught Place, New Delhi", Point: Point{Lat: 28.6315, Lng: 77.2167}},
+	} {
+		provider.AddPlace(place)
+	}
+
+	return provider
+}
+
+func (provider *FakeProvider) AddPlace(place FakePlace) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.places[place.PlaceId] = place
+}
+
+// AddRoute overrides the straight route between two places.
+func (provider *FakeProvider) AddRoute(origin, destination string, route Route) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.routes[origin+"|"+destination] = route
+}
+
+// Calls returns how many times a method was called, to check caching.
+func (provider *FakeProvider) Calls(method string) int {
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+
+	return provider.calls[method]
+}
+
+func (provider *FakeProvider) record(method string) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.calls[method]++
+}
+
+// lookup finds a place by id or, as routes are requested with free text, by
+// its exact description.
+func (provider *FakeProvider) lookup(place string) (FakePlace, bool) {
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+
+	if found, ok := provider.places[place]; ok {
+		return found, true
+	}
+	for _, found := range provider.places {
+		if strings.EqualFold(found.Description, place) {
+			return found, true
+		}
+	}
+
+	return FakePlace{}, false
+}
+
+// PlacePredictions ignores the bias and countries, every place being in Noida.
+func (provider *FakeProvider) PlacePredictions(ctx context.Context, input string, options PredictionOptions) ([]Predictions, error) {
+	provider.record("PlacePredictions")
+
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+
+	var predictions []Predictions
+	for _, place := range provider.places {
+		if strings.Contains(strings.ToLower(place.Description), strings.ToLower(input)) {
+			predictions = append(predictions, Predictions{Description: place.Description, PlaceId: place.PlaceId})
+		}
+	}
+
+	sort.Slice(predictions, func(i, j int) bool {
+		return predictions[i].Description < predictions[j].Description
+	})
+	if len(predictions) > options.limit() {
+		predictions = predictions[:options.limit()]
+	}
+
+	return predictions, nil
+}
+
+func (provider *FakeProvider) PlaceDetails(ctx context.Context, placeId string) (Place, error) {
+	provider.record("PlaceDetails")
+
+	place, ok := provider.lookup(placeId)
+	if !ok {
+		return Place{}, ErrInvalidPlace
+	}
+
+	return Place{Lat: place.Point.Lat, Lng: place.Point.Lng}, nil
+}
+
+func (provider *FakeProvider) Route(ctx context.Context, origin, destination string) (Route, error) {
+	provider.record("Route")
+
+	provider.mu.RLock()
+	route, ok := provider.routes[origin+"|"+destination]
+	provider.mu.RUnlock()
+	if ok {
+		if len(route.Points) == 0 {
+			return route, ErrNoRoute
+		}
+		return route, nil
+	}
+
+	from, ok := provider.lookup(origin)
+	if !ok {
+		return Route{}, ErrInvalidPlace
+	}
+	to, ok := provider.lookup(destination)
+	if !ok {
+		return Route{}, ErrInvalidPlace
+	}
+
+	for i := 0; i <= fakeRouteSteps; i++ {
+		t := float64(i) / fakeRouteSteps
+		route.Points = append(route.Points, Point{
+			Lat: from.Point.Lat + t*(to.Point.Lat-from.Point.Lat),
+			Lng: from.Point.Lng + t*(to.Point.Lng-from.Point.Lng),
+		})
+	}
+	route.Path = route.Points
+	route.Bounds = pathBounds(route.Path)
+
+	return route, nil
+}
+
+func (provider *FakeProvider) Coordinates(ctx context.Context, placeId string) (Point, error) {
+	provider.record("Coordinates")
+
+	place, ok := provider.lookup(placeId)
+	if !ok {
+		return Point{}, ErrInvalidPlace
+	}
+
+	return place.Point, nil
+}
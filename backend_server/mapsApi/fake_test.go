@@ -0,0 +1,30 @@
+package mapsApi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderRoute(t *testing.T) {
+	provider := NewFakeProvider()
+
+	route, err := provider.Route(context.Background(), "fake-amity-university", "Sector 18 Market, Noida")
+	require.NoError(t, err)
+	require.Len(t, route.Path, fakeRouteSteps+1)
+	require.Equal(t, Point{Lat: 28.5440, Lng: 77.3331}, route.Path[0])
+	require.Equal(t, Point{Lat: 28.5708, Lng: 77.3261}, route.Path[fakeRouteSteps])
+
+	_, err = provider.Route(context.Background(), "fake-amity-university", "nowhere")
+	require.ErrorIs(t, err, ErrInvalidPlace)
+}
+
+func TestFakeProviderPredictions(t *testing.T) {
+	provider := NewFakeProvider()
+
+	predictions, err := provider.PlacePredictions(context.Background(), "noida", PredictionOptions{})
+	require.NoError(t, err)
+	require.Len(t, predictions, 4)
+	require.Equal(t, "fake-amity-university", predictions[0].PlaceId)
+}
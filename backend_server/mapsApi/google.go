@@ -0,0 +1,181 @@
+package mapsApi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const googleBaseURL = "https://maps.googleapis.com/maps/api"
+
+// GoogleProvider talks to the Google Places, Directions and Geocoding APIs.
+type GoogleProvider struct {
+	key     string
+	baseURL string
+	client  *Client
+}
+
+func NewGoogleProvider(key string, config ClientConfig) *GoogleProvider {
+	return &GoogleProvider{
+		key:     key,
+		baseURL: googleBaseURL,
+		client:  NewClient(config),
+	}
+}
+
+func (provider *GoogleProvider) Degraded() bool {
+	return provider.client.Degraded()
+}
+
+func (provider *GoogleProvider) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	query.Set("key", provider.key)
+
+	return provider.client.getJSON(ctx, provider.baseURL+path+"?"+query.Encode(), nil, result)
+}
+
+func (provider *GoogleProvider) PlacePredictions(ctx context.Context, input string, options PredictionOptions) ([]Predictions, error) {
+	var predictionList PredictionList
+
+	query := url.Values{}
+	query.Set("input", input)
+
+	switch bias := options.Bias; {
+	case bias.Center != nil:
+		query.Set("locationbias", fmt.Sprintf("circle:%.0f@%f,%f", bias.Radius, bias.Center.Lat, bias.Center.Lng))
+	case bias.Bounds != nil:
+		query.Set("locationbias", fmt.Sprintf("rectangle:%f,%f|%f,%f", bias.Bounds.LatSW, bias.Bounds.LngSW, bias.Bounds.LatNE, bias.Bounds.LngNE))
+	}
+
+	var components []string
+	for _, country := range options.Countries {
+		components = append(components, "country:"+strings.ToLower(country))
+	}
+	if len(components) > 0 {
+		query.Set("components", strings.Join(components, "|"))
+	}
+
+	if options.SessionToken != "" {
+		query.Set("sessiontoken", options.SessionToken)
+	}
+
+	if err := provider.get(ctx, "/place/autocomplete/json", query, &predictionList); err != nil {
+		return nil, fmt.Errorf("cannot get place recommendations : %w", err)
+	}
+	if err := predictionList.err(nil); err != nil {
+		return nil, fmt.Errorf("cannot get place recommendations : %w", err)
+	}
+
+	var predictions []Predictions
+	for _, prediction := range predictionList.Predictions {
+		if len(predictions) == options.limit() {
+			break
+		}
+		predictions = append(predictions, Predictions{
+			Description: prediction.Description,
+			PlaceId:     prediction.PlaceID,
+		})
+	}
+
+	return predictions, nil
+}
+
+func (provider *GoogleProvider) PlaceDetails(ctx context.Context, placeId string) (Place, error) {
+	var placeDetail AutoGenerated
+
+	query := url.Values{}
+	query.Set("placeid", placeId)
+	if token := sessionToken(ctx); token != "" {
+		query.Set("sessiontoken", token)
+	}
+
+	if err := provider.get(ctx, "/place/details/json", query, &placeDetail); err != nil {
+		return Place{}, fmt.Errorf("cannot get place details : %w", err)
+	}
+	if err := placeDetail.err(ErrInvalidPlace); err != nil {
+		return Place{}, fmt.Errorf("cannot get place details : %w", err)
+	}
+
+	place := Place{
+		Lat: placeDetail.Result.Geometry.Location.Lat,
+		Lng: placeDetail.Result.Geometry.Location.Lng,
+	}
+
+	return place, nil
+}
+
+func (provider *GoogleProvider) Route(ctx context.Context, origin, destination string) (Route, error) {
+	var route Route
+	var polyPoints PolyPoints
+
+	query := url.Values{}
+	query.Set("origin", origin)
+	query.Set("destination", destination)
+
+	if err := provider.get(ctx, "/directions/json", query, &polyPoints); err != nil {
+		return route, fmt.Errorf("cannot get route : %w", err)
+	}
+	if err := polyPoints.err(ErrNoRoute); err != nil {
+		return route, fmt.Errorf("cannot get route : %w", err)
+	}
+	if len(polyPoints.Routes) == 0 || len(polyPoints.Routes[0].Legs) == 0 {
+		return route, ErrNoRoute
+	}
+
+	route.Bounds = Bounds{
+		LatNE: polyPoints.Routes[0].Bounds.Northeast.Lat,
+		LngNE: polyPoints.Routes[0].Bounds.Northeast.Lng,
+		LatSW: polyPoints.Routes[0].Bounds.Southwest.Lat,
+		LngSW: polyPoints.Routes[0].Bounds.Southwest.Lng,
+	}
+
+	for _, step := range polyPoints.Routes[0].Legs[0].Steps {
+		route.Points = append(route.Points, Point{
+			Lat: step.StartLocation.Lat,
+			Lng: step.StartLocation.Lng,
+		})
+	}
+
+	// * Callers take the origin of the ride from the first point
+	if len(route.Points) == 0 {
+		return route, ErrNoRoute
+	}
+
+	route.Path = DecodePolyline(polyPoints.Routes[0].OverviewPolyline.Points)
+
+	return route, nil
+}
+
+func (provider *GoogleProvider) Coordinates(ctx context.Context, placeId string) (Point, error) {
+	var point Point
+	var cords Cords
+
+	// * Geocoding does not take session tokens, only place details closes a session
+	if sessionToken(ctx) != "" {
+		place, err := provider.PlaceDetails(ctx, placeId)
+		if err != nil {
+			return point, err
+		}
+		return Point{Lat: place.Lat, Lng: place.Lng}, nil
+	}
+
+	query := url.Values{}
+	query.Set("place_id", placeId)
+
+	if err := provider.get(ctx, "/geocode/json", query, &cords); err != nil {
+		return point, fmt.Errorf("cannot get coordinates : %w", err)
+	}
+	if err := cords.err(ErrInvalidPlace); err != nil {
+		return point, fmt.Errorf("cannot get coordinates : %w", err)
+	}
+	if len(cords.Results) == 0 {
+		return point, ErrInvalidPlace
+	}
+
+	point = Point{
+		Lat: cords.Results[0].Geometry.Location.Lat,
+		Lng: cords.Results[0].Geometry.Location.Lng,
+	}
+
+	return point, nil
+}
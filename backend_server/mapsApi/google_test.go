@@ -0,0 +1,138 @@
+package mapsApi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newGoogleTestProvider answers every call with the status code and body.
+func newGoogleTestProvider(t *testing.T, code int, body string) *GoogleProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test-key", r.URL.Query().Get("key"))
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	provider := NewGoogleProvider("test-key", ClientConfig{Backoff: time.Millisecond})
+	provider.baseURL = server.URL
+
+	return provider
+}
+
+func TestGoogleProviderRouteErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		code int
+		body string
+		err  error
+	}{
+		{"ZeroResults", http.StatusOK, `{"status":"ZERO_RESULTS","routes":[]}`, ErrNoRoute},
+		{"NotFound", http.StatusOK, `{"status":"NOT_FOUND","routes":[]}`, ErrInvalidPlace},
+		{"Denied", http.StatusOK, `{"status":"REQUEST_DENIED","error_message":"The provided API key is invalid."}`, ErrDenied},
+		{"QueryLimit", http.StatusOK, `{"status":"OVER_QUERY_LIMIT"}`, ErrQuotaExceeded},
+		{"TooManyRequests", http.StatusTooManyRequests, ``, ErrQuotaExceeded},
+		{"OkWithoutRoutes", http.StatusOK, `{"status":"OK","routes":[]}`, ErrNoRoute},
+		{"OkWithoutSteps", http.StatusOK, `{"status":"OK","routes":[{"legs":[{"steps":[]}]}]}`, ErrNoRoute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := newGoogleTestProvider(t, tc.code, tc.body)
+
+			_, err := provider.Route(context.Background(), "origin", "destination")
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestGoogleProviderServerError(t *testing.T) {
+	provider := newGoogleTestProvider(t, http.StatusInternalServerError, `oops`)
+
+	_, err := provider.Coordinates(context.Background(), "place")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrInvalidPlace)
+}
+
+func TestGoogleProviderCoordinatesZeroResults(t *testing.T) {
+	provider := newGoogleTestProvider(t, http.StatusOK, `{"status":"ZERO_RESULTS","results":[]}`)
+
+	_, err := provider.Coordinates(context.Background(), "place")
+	require.ErrorIs(t, err, ErrInvalidPlace)
+}
+
+func TestGoogleProviderPredictionsZeroResults(t *testing.T) {
+	provider := newGoogleTestProvider(t, http.StatusOK, `{"status":"ZERO_RESULTS","predictions":[]}`)
+
+	predictions, err := provider.PlacePredictions(context.Background(), "nowhere", PredictionOptions{})
+	require.NoError(t, err)
+	require.Empty(t, predictions)
+}
+
+func TestGoogleProviderPredictionsInvalidBody(t *testing.T) {
+	provider := newGoogleTestProvider(t, http.StatusOK, `<html>`)
+
+	_, err := provider.PlacePredictions(context.Background(), "amity", PredictionOptions{})
+	require.Error(t, err)
+}
+
+func TestGoogleProviderPredictionOptions(t *testing.T) {
+	var query url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"status":"OK","predictions":[{"description":"a","place_id":"1"},{"description":"b","place_id":"2"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider := NewGoogleProvider("test-key", ClientConfig{})
+	provider.baseURL = server.URL
+
+	predictions, err := provider.PlacePredictions(context.Background(), "amity", PredictionOptions{
+		Bias:         LocationBias{Center: &Point{Lat: 28.544, Lng: 77.3331}, Radius: 20000},
+		Countries:    []string{"IN", "np"},
+		SessionToken: "session",
+		Limit:        1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Predictions{{Description: "a", PlaceId: "1"}}, predictions)
+	require.Equal(t, "circle:20000@28.544000,77.333100", query.Get("locationbias"))
+	require.Equal(t, "country:in|country:np", query.Get("components"))
+	require.Equal(t, "session", query.Get("sessiontoken"))
+
+	_, err = provider.PlacePredictions(context.Background(), "amity", PredictionOptions{
+		Bias: LocationBias{Bounds: &Bounds{LatSW: 28.4, LngSW: 77.2, LatNE: 28.7, LngNE: 77.5}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rectangle:28.400000,77.200000|28.700000,77.500000", query.Get("locationbias"))
+	require.Empty(t, query.Get("components"))
+	require.Empty(t, query.Get("sessiontoken"))
+}
+
+func TestGoogleProviderCoordinatesClosesSession(t *testing.T) {
+	var path string
+	var query url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		fmt.Fprint(w, `{"status":"OK","result":{"geometry":{"location":{"lat":28.5,"lng":77.3}}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider := NewGoogleProvider("test-key", ClientConfig{})
+	provider.baseURL = server.URL
+
+	point, err := provider.Coordinates(WithSessionToken(context.Background(), "session"), "place")
+	require.NoError(t, err)
+	require.Equal(t, Point{Lat: 28.5, Lng: 77.3}, point)
+	require.Equal(t, "/place/details/json", path)
+	require.Equal(t, "session", query.Get("sessiontoken"))
+	require.Equal(t, "place", query.Get("placeid"))
+}
@@ -1,16 +1,13 @@
 package mapsApi
 
-import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-
-	"github.com/achintya-7/car_pooling_backend/utils"
-)
+// GoogleStatus is the status every Google maps api response carries.
+type GoogleStatus struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+}
 
 type PredictionList struct {
+	GoogleStatus
 	Predictions []struct {
 		Description string `json:"description"`
 		PlaceID     string `json:"place_id"`
@@ -124,7 +121,7 @@ type AutoGenerated struct {
 		Vicinity                     string   `json:"vicinity"`
 		WheelchairAccessibleEntrance bool     `json:"wheelchair_accessible_entrance"`
 	} `json:"result"`
-	Status string `json:"status"`
+	GoogleStatus
 }
 
 type Location struct {
@@ -138,6 +135,7 @@ type Place struct {
 }
 
 type PolyPoints struct {
+	GoogleStatus
 	Routes []struct {
 		Bounds struct {
 			Northeast struct {
@@ -149,6 +147,9 @@ type PolyPoints struct {
 				Lng float64 `json:"lng"`
 			} `json:"southwest"`
 		} `json:"bounds"`
+		OverviewPolyline struct {
+			Points string `json:"points"`
+		} `json:"overview_polyline"`
 		Legs []struct {
 			Steps []struct {
 				StartLocation struct {
@@ -174,10 +175,12 @@ type Bounds struct {
 
 type Route struct {
 	Points []Point `json:"points"`
+	Path   []Point `json:"path"` // full route geometry from the overview polyline
 	Bounds Bounds  `json:"bounds"`
 }
 
 type Cords struct {
+	GoogleStatus
 	Results []struct {
 		Geometry struct {
 			Bounds struct {
@@ -197,136 +200,3 @@ type Cords struct {
 		} `json:"geometry"`
 	} `json:"results"`
 }
-
-func GetPlacePredictions(input string, config utils.Config) ([]Predictions, error) {
-	location := "Northern India"
-
-	apiUrl := fmt.Sprintf("https://maps.googleapis.com/maps/api/place/autocomplete/json?input=%s&location=%s&maxresults=6&key=%s", url.QueryEscape(input), url.QueryEscape(location), config.MapsKey)
-
-	resp, err := http.Get(apiUrl)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get place recommendations : %v", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read response body : %v", err)
-	}
-
-	var predictions []Predictions
-
-	var predictionList PredictionList
-	err = json.Unmarshal(body, &predictionList)
-	if err != nil {
-		print("cannot unmarshal response body : %v", err)
-	}
-
-	for _, prediction := range predictionList.Predictions {
-		predictions = append(predictions, Predictions{
-			Description: prediction.Description,
-			PlaceId:     prediction.PlaceID,
-		})
-	}
-
-	return predictions, nil
-
-}
-
-// * Not using now, Error in struct as well
-func GetPlaceDetails(placeId string, config utils.Config) (Place, error) {
-	apiUrl := fmt.Sprintf("https://maps.googleapis.com/maps/api/place/details/json?placeid=%s&key=%s", url.QueryEscape(placeId), config.MapsKey)
-
-	resp, err := http.Get(apiUrl)
-	if err != nil {
-		return Place{}, fmt.Errorf("cannot get place details : %v", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return Place{}, fmt.Errorf("cannot read response body : %v", err)
-	}
-
-	fmt.Println(body)
-
-	var placeDetail AutoGenerated
-	err = json.Unmarshal(body, &placeDetail)
-	if err != nil {
-		return Place{}, fmt.Errorf("cannot unmarshal response body : %v", err)
-	} else {
-		fmt.Println(placeDetail)
-	}
-
-	place := Place{
-		Lat: placeDetail.Result.Geometry.Location.Lat,
-		Lng: placeDetail.Result.Geometry.Location.Lng,
-	}
-
-	return place, nil
-}
-
-func GetRoute(origin, destination string, config utils.Config) (Route, error) {
-	var route Route
-
-	apiUrl := fmt.Sprintf("https://maps.googleapis.com/maps/api/directions/json?origin=%s&destination=%s&key=%s", url.QueryEscape(origin), url.QueryEscape(destination), config.MapsKey)
-
-	resp, err := http.Get(apiUrl)
-	if err != nil {
-		return route, fmt.Errorf("cannot get route : %v", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return route, fmt.Errorf("cannot read response body : %v", err)
-	}
-
-	var polyPoints PolyPoints
-	err = json.Unmarshal(body, &polyPoints)
-	if err != nil {
-		return route, fmt.Errorf("cannot unmarshal response body : %v", err)
-	}
-
-	route.Bounds = Bounds{
-		LatNE: polyPoints.Routes[0].Bounds.Northeast.Lat,
-		LngNE: polyPoints.Routes[0].Bounds.Northeast.Lng,
-		LatSW: polyPoints.Routes[0].Bounds.Southwest.Lat,
-		LngSW: polyPoints.Routes[0].Bounds.Southwest.Lng,
-	}
-
-	for _, step := range polyPoints.Routes[0].Legs[0].Steps {
-		route.Points = append(route.Points, Point{
-			Lat: step.StartLocation.Lat,
-			Lng: step.StartLocation.Lng,
-		})
-	}
-
-	return route, nil
-}
-
-func GetCords(placeId string, config utils.Config) (Point, error) {
-	var point Point
-
-	apiUrl := fmt.Sprintf("https://maps.googleapis.com/maps/api/geocode/json?place_id=%s&key=%s", url.QueryEscape(placeId), config.MapsKey)
-
-	resp, err := http.Get(apiUrl)
-	if err != nil {
-		return point, fmt.Errorf("cannot get route : %v", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return point, fmt.Errorf("cannot read response body : %v", err)
-	}
-
-	var cords Cords
-	err = json.Unmarshal(body, &cords)
-	if err != nil {
-		return point, fmt.Errorf("cannot unmarshal response body : %v", err)
-	}
-
-	point = Point{
-		Lat: cords.Results[0].Geometry.Location.Lat,
-		Lng: cords.Results[0].Geometry.Location.Lng,
-	}
-
-	return point, nil
-}
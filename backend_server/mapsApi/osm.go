@@ -0,0 +1,281 @@
+package mapsApi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const osmUserAgent = "car_pooling_backend"
+
+// * Place ids are the osm type initial followed by the osm id, as taken by the lookup api
+var osmPlaceIdPattern = regexp.MustCompile(`^[NWR][0-9]+$`)
+
+type nominatimPlace struct {
+	OsmType     string `json:"osm_type"`
+	OsmID       int64  `json:"osm_id"`
+	DisplayName string `json:"display_name"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+}
+
+func (place nominatimPlace) placeId() string {
+	if place.OsmType == "" {
+		return ""
+	}
+	return strings.ToUpper(place.OsmType[:1]) + strconv.FormatInt(place.OsmID, 10)
+}
+
+func (place nominatimPlace) point() (Point, error) {
+	lat, err := strconv.ParseFloat(place.Lat, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude %q", place.Lat)
+	}
+	lng, err := strconv.ParseFloat(place.Lon, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude %q", place.Lon)
+	}
+
+	return Point{Lat: lat, Lng: lng}, nil
+}
+
+type osrmRoute struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Routes  []struct {
+		Geometry string `json:"geometry"`
+		Legs     []struct {
+			Steps []struct {
+				Maneuver struct {
+					Location []float64 `json:"location"` // lng, lat
+				} `json:"maneuver"`
+			} `json:"steps"`
+		} `json:"legs"`
+	} `json:"routes"`
+}
+
+func (osrm osrmRoute) err() error {
+	var err error
+
+	switch osrm.Code {
+	case "Ok":
+		if len(osrm.Routes) == 0 {
+			return ErrNoRoute
+		}
+		return nil
+	case "NoRoute", "NoSegment":
+		return ErrNoRoute
+	case "InvalidQuery", "InvalidValue", "InvalidUrl":
+		err = ErrInvalidPlace
+	default:
+		err = fmt.Errorf("unexpected code %q", osrm.Code)
+	}
+
+	if osrm.Message != "" {
+		return fmt.Errorf("%w : %s", err, osrm.Message)
+	}
+	return err
+}
+
+// OSMProvider geocodes with Nominatim and routes with OSRM, both can be self
+// hosted.
+type OSMProvider struct {
+	nominatimURL string
+	osrmURL      string
+	nominatim    *Client
+	osrm         *Client
+}
+
+// NewOSMProvider needs both servers, the public OpenStreetMap ones do not
+// allow production traffic.
+func NewOSMProvider(nominatimURL, osrmURL string, config ClientConfig) (*OSMProvider, error) {
+	if nominatimURL == "" {
+		return nil, errors.New("nominatim url is required")
+	}
+	if osrmURL == "" {
+		return nil, errors.New("osrm url is required")
+	}
+
+	return &OSMProvider{
+		nominatimURL: strings.TrimSuffix(nominatimURL, "/"),
+		osrmURL:      strings.TrimSuffix(osrmURL, "/"),
+		nominatim:    NewClient(config),
+		osrm:         NewClient(config),
+	}, nil
+}
+
+func (provider *OSMProvider) Degraded() bool {
+	return provider.nominatim.Degraded() || provider.osrm.Degraded()
+}
+
+func (provider *OSMProvider) geocode(ctx context.Context, path string, query url.Values) ([]nominatimPlace, error) {
+	var places []nominatimPlace
+
+	query.Set("format", "jsonv2")
+
+	// * Nominatim usage policy requires identifying the application
+	header := http.Header{"User-Agent": {osmUserAgent}}
+
+	err := provider.nominatim.getJSON(ctx, provider.nominatimURL+path+"?"+query.Encode(), header, &places)
+	return places, err
+}
+
+func (provider *OSMProvider) PlacePredictions(ctx context.Context, input string, options PredictionOptions) ([]Predictions, error) {
+	query := url.Values{}
+	query.Set("q", input)
+	query.Set("limit", strconv.Itoa(options.limit()))
+
+	// * Without bounded the viewbox only biases the results
+	if bounds := options.Bias.bounds(); bounds != nil {
+		query.Set("viewbox", fmt.Sprintf("%f,%f,%f,%f", bounds.LngSW, bounds.LatSW, bounds.LngNE, bounds.LatNE))
+	}
+	if len(options.Countries) > 0 {
+		query.Set("countrycodes", strings.ToLower(strings.Join(options.Countries, ",")))
+	}
+
+	places, err := provider.geocode(ctx, "/search", query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get place recommendations : %w", err)
+	}
+
+	var predictions []Predictions
+	for _, place := range places {
+		predictions = append(predictions, Predictions{
+			Description: place.DisplayName,
+			PlaceId:     place.placeId(),
+		})
+	}
+
+	return predictions, nil
+}
+
+func (provider *OSMProvider) PlaceDetails(ctx context.Context, placeId string) (Place, error) {
+	point, err := provider.Coordinates(ctx, placeId)
+	if err != nil {
+		return Place{}, fmt.Errorf("cannot get place details : %w", err)
+	}
+
+	return Place{Lat: point.Lat, Lng: point.Lng}, nil
+}
+
+func (provider *OSMProvider) Coordinates(ctx context.Context, placeId string) (Point, error) {
+	if !osmPlaceIdPattern.MatchString(placeId) {
+		return Point{}, ErrInvalidPlace
+	}
+
+	query := url.Values{}
+	query.Set("osm_ids", placeId)
+
+	places, err := provider.geocode(ctx, "/lookup", query)
+	if err != nil {
+		return Point{}, fmt.Errorf("cannot get coordinates : %w", err)
+	}
+	if len(places) == 0 {
+		return Point{}, ErrInvalidPlace
+	}
+
+	return places[0].point()
+}
+
+// resolve finds the coordinates of a place id or, like the Google directions
+// api allows, of a free text address.
+func (provider *OSMProvider) resolve(ctx context.Context, place string) (Point, error) {
+	if osmPlaceIdPattern.MatchString(place) {
+		return provider.Coordinates(ctx, place)
+	}
+
+	query := url.Values{}
+	query.Set("q", place)
+	query.Set("limit", "1")
+
+	places, err := provider.geocode(ctx, "/search", query)
+	if err != nil {
+		return Point{}, err
+	}
+	if len(places) == 0 {
+		return Point{}, ErrInvalidPlace
+	}
+
+	return places[0].point()
+}
+
+func (provider *OSMProvider) Route(ctx context.Context, origin, destination string) (Route, error) {
+	var route Route
+	var osrm osrmRoute
+
+	from, err := provider.resolve(ctx, origin)
+	if err != nil {
+		return route, fmt.Errorf("cannot get route origin : %w", err)
+	}
+	to, err := provider.resolve(ctx, destination)
+	if err != nil {
+		return route, fmt.Errorf("cannot get route destination : %w", err)
+	}
+
+	// * OSRM takes lng,lat pairs
+	coordinates := fmt.Sprintf("%f,%f;%f,%f", from.Lng, from.Lat, to.Lng, to.Lat)
+
+	query := url.Values{}
+	query.Set("overview", "full")
+	query.Set("geometries", "polyline")
+	query.Set("steps", "true")
+
+	// * OSRM answers 400 for unroutable coordinates, the code tells why
+	err = provider.osrm.getJSON(ctx, provider.osrmURL+"/route/v1/driving/"+coordinates+"?"+query.Encode(), nil, &osrm)
+	if err != nil && osrm.Code == "" {
+		return route, fmt.Errorf("cannot get route : %w", err)
+	}
+	if err := osrm.err(); err != nil {
+		return route, fmt.Errorf("cannot get route : %w", err)
+	}
+
+	for _, leg := range osrm.Routes[0].Legs {
+		for _, step := range leg.Steps {
+			if len(step.Maneuver.Location) != 2 {
+				continue
+			}
+			route.Points = append(route.Points, Point{
+				Lat: step.Maneuver.Location[1],
+				Lng: step.Maneuver.Location[0],
+			})
+		}
+	}
+
+	// * Callers take the origin of the ride from the first point
+	if len(route.Points) == 0 {
+		return route, ErrNoRoute
+	}
+
+	route.Path = DecodePolyline(osrm.Routes[0].Geometry)
+	route.Bounds = pathBounds(route.Path)
+
+	return route, nil
+}
+
+// pathBounds is the smallest box holding every point of the path.
+func pathBounds(path []Point) Bounds {
+	if len(path) == 0 {
+		return Bounds{}
+	}
+
+	bounds := Bounds{
+		LatNE: math.Inf(-1),
+		LngNE: math.Inf(-1),
+		LatSW: math.Inf(1),
+		LngSW: math.Inf(1),
+	}
+	for _, point := range path {
+		bounds.LatNE = math.Max(bounds.LatNE, point.Lat)
+		bounds.LngNE = math.Max(bounds.LngNE, point.Lng)
+		bounds.LatSW = math.Min(bounds.LatSW, point.Lat)
+		bounds.LngSW = math.Min(bounds.LngSW, point.Lng)
+	}
+
+	return bounds
+}
@@ -0,0 +1,142 @@
+package mapsApi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newOSMTestServer stands in for Nominatim and OSRM with a route from Amity
+// University to Sector 18.
+func newOSMTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+		require.NotEmpty(t, r.Header.Get("User-Agent"))
+
+		if r.URL.Query().Get("countrycodes") != "" {
+			require.Equal(t, "in", r.URL.Query().Get("countrycodes"))
+			require.Equal(t, "77.200000,28.400000,77.500000,28.700000", r.URL.Query().Get("viewbox"))
+			require.Equal(t, "1", r.URL.Query().Get("limit"))
+		}
+
+		switch r.URL.Query().Get("q") {
+		case "amity":
+			fmt.Fprint(w, `[{"osm_type":"way","osm_id":1234,"display_name":"Amity University, Noida","lat":"28.5440","lon":"77.3331"},
+				{"osm_type":"node","osm_id":99,"display_name":"Amity School, Noida","lat":"28.5","lon":"77.3"}]`)
+		case "Sector 18, Noida":
+			fmt.Fprint(w, `[{"osm_type":"relation","osm_id":42,"display_name":"Sector 18, Noida","lat":"28.5708","lon":"77.3261"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	mux.HandleFunc("/lookup", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("osm_ids") {
+		case "W1234":
+			fmt.Fprint(w, `[{"osm_type":"way","osm_id":1234,"display_name":"Amity University, Noida","lat":"28.5440","lon":"77.3331"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	mux.HandleFunc("/route/v1/driving/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/route/v1/driving/77.333100,28.544000;77.326100,28.570800", r.URL.Path)
+		require.Equal(t, "polyline", r.URL.Query().Get("geometries"))
+
+		fmt.Fprint(w, `{"code":"Ok","routes":[{"geometry":"_p~iF~ps|U_ulLnnqC_mqNvxq`+"`"+`@","legs":[{"steps":[
+			{"maneuver":{"location":[77.3331,28.544]}},
+			{"maneuver":{"location":[77.3261,28.5708]}}]}]}]}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOSMProviderPredictions(t *testing.T) {
+	server := newOSMTestServer(t)
+	provider, err := NewOSMProvider(server.URL, server.URL, ClientConfig{})
+	require.NoError(t, err)
+
+	predictions, err := provider.PlacePredictions(context.Background(), "amity", PredictionOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []Predictions{
+		{Description: "Amity University, Noida", PlaceId: "W1234"},
+		{Description: "Amity School, Noida", PlaceId: "N99"},
+	}, predictions)
+
+	_, err = provider.PlacePredictions(context.Background(), "amity", PredictionOptions{
+		Bias:      LocationBias{Bounds: &Bounds{LatSW: 28.4, LngSW: 77.2, LatNE: 28.7, LngNE: 77.5}},
+		Countries: []string{"IN"},
+		Limit:     1,
+	})
+	require.NoError(t, err)
+}
+
+func TestOSMProviderCoordinates(t *testing.T) {
+	server := newOSMTestServer(t)
+	provider, err := NewOSMProvider(server.URL, server.URL, ClientConfig{})
+	require.NoError(t, err)
+
+	point, err := provider.Coordinates(context.Background(), "W1234")
+	require.NoError(t, err)
+	require.Equal(t, Point{Lat: 28.5440, Lng: 77.3331}, point)
+
+	_, err = provider.Coordinates(context.Background(), "N1")
+	require.ErrorIs(t, err, ErrInvalidPlace)
+
+	// * Ids of other providers are not sent to the lookup api
+	_, err = provider.Coordinates(context.Background(), "ChIJ2dGMjMMEdkgRqVqkuXQkj7c")
+	require.ErrorIs(t, err, ErrInvalidPlace)
+}
+
+func TestNewOSMProviderRequiresURLs(t *testing.T) {
+	_, err := NewOSMProvider("", "http://osrm.local", ClientConfig{})
+	require.Error(t, err)
+
+	_, err = NewOSMProvider("http://nominatim.local", "", ClientConfig{})
+	require.Error(t, err)
+}
+
+func TestOSMProviderRoute(t *testing.T) {
+	server := newOSMTestServer(t)
+	provider, err := NewOSMProvider(server.URL, server.URL, ClientConfig{})
+	require.NoError(t, err)
+
+	// * Place ids and free text can be mixed like with Google
+	route, err := provider.Route(context.Background(), "W1234", "Sector 18, Noida")
+	require.NoError(t, err)
+	require.Equal(t, []Point{{Lat: 28.544, Lng: 77.3331}, {Lat: 28.5708, Lng: 77.3261}}, route.Points)
+	require.Equal(t, []Point{
+		{Lat: 38.5, Lng: -120.2},
+		{Lat: 40.7, Lng: -120.95},
+		{Lat: 43.252, Lng: -126.453},
+	}, route.Path)
+	require.Equal(t, Bounds{LatNE: 43.252, LngNE: -120.2, LatSW: 38.5, LngSW: -126.453}, route.Bounds)
+
+	_, err = provider.Route(context.Background(), "W1234", "nowhere")
+	require.Error(t, err)
+}
+
+func TestOSMProviderNoRoute(t *testing.T) {
+	nominatim := newOSMTestServer(t)
+
+	osrm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":"NoRoute","message":"Impossible route between points"}`)
+	}))
+	t.Cleanup(osrm.Close)
+
+	provider, err := NewOSMProvider(nominatim.URL, osrm.URL, ClientConfig{})
+	require.NoError(t, err)
+
+	_, err = provider.Route(context.Background(), "W1234", "Sector 18, Noida")
+	require.ErrorIs(t, err, ErrNoRoute)
+}
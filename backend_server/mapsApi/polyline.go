@@ -0,0 +1,48 @@
+package mapsApi
+
+// DecodePolyline decodes a Google encoded polyline into its points.
+// https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+func DecodePolyline(encoded string) []Point {
+	var points []Point
+	var lat, lng int
+
+	for index := 0; index < len(encoded); {
+		var ok bool
+		var delta int
+
+		if delta, index, ok = decodePolylineValue(encoded, index); !ok {
+			break
+		}
+		lat += delta
+
+		if delta, index, ok = decodePolylineValue(encoded, index); !ok {
+			break
+		}
+		lng += delta
+
+		points = append(points, Point{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+
+	return points
+}
+
+func decodePolylineValue(encoded string, index int) (int, int, bool) {
+	result, shift := 0, 0
+
+	for index < len(encoded) {
+		b := int(encoded[index]) - 63
+		index++
+
+		result |= (b & 0x1f) << shift
+		shift += 5
+
+		if b < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), index, true
+			}
+			return result >> 1, index, true
+		}
+	}
+
+	return 0, index, false
+}
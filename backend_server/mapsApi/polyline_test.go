@@ -0,0 +1,25 @@
+package mapsApi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	// * Example from the polyline algorithm documentation
+	points := DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+
+	require.Equal(t, []Point{
+		{Lat: 38.5, Lng: -120.2},
+		{Lat: 40.7, Lng: -120.95},
+		{Lat: 43.252, Lng: -126.453},
+	}, points)
+}
+
+func TestDecodePolylineTruncated(t *testing.T) {
+	require.Empty(t, DecodePolyline(""))
+
+	// * A latitude without its longitude is dropped
+	require.Len(t, DecodePolyline("_p~iF~ps|U_ulL"), 1)
+}
@@ -0,0 +1,99 @@
+package mapsApi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultPredictionsLimit is the most predictions Google autocomplete returns.
+const DefaultPredictionsLimit = 5
+
+// LocationBias favours places around Center, or inside Bounds when there is
+// no center. Places outside are still returned.
+type LocationBias struct {
+	Center *Point
+	Radius float64 // meters
+	Bounds *Bounds
+}
+
+// bounds is the box holding the circle around the center, or Bounds.
+func (bias LocationBias) bounds() *Bounds {
+	if bias.Center == nil {
+		return bias.Bounds
+	}
+
+	// * Meters per degree of latitude, longitude degrees shrink with the latitude
+	latDelta := bias.Radius / 111320
+	lngDelta := bias.Radius / (111320 * math.Cos(bias.Center.Lat*math.Pi/180))
+
+	return &Bounds{
+		LatNE: bias.Center.Lat + latDelta,
+		LngNE: bias.Center.Lng + lngDelta,
+		LatSW: bias.Center.Lat - latDelta,
+		LngSW: bias.Center.Lng - lngDelta,
+	}
+}
+
+type PredictionOptions struct {
+	Bias      LocationBias
+	Countries []string // ISO 3166-1 alpha-2 codes the places must be in
+	// * Groups the autocomplete calls of one search for billing, never cached. The
+	// * session is closed by the place call made with WithSessionToken
+	SessionToken string
+	Limit        int
+}
+
+func (options PredictionOptions) limit() int {
+	if options.Limit == 0 {
+		return DefaultPredictionsLimit
+	}
+	return options.Limit
+}
+
+// cacheKey tells apart the options changing the predictions. Centers are
+// rounded to about a kilometer so nearby callers share their predictions.
+func (options PredictionOptions) cacheKey() string {
+	var bias string
+	switch {
+	case options.Bias.Center != nil:
+		bias = fmt.Sprintf("%.2f,%.2f,%.0f", options.Bias.Center.Lat, options.Bias.Center.Lng, options.Bias.Radius)
+	case options.Bias.Bounds != nil:
+		bias = fmt.Sprintf("%v", *options.Bias.Bounds)
+	}
+
+	return fmt.Sprintf("%s|%s|%d", bias, strings.ToLower(strings.Join(options.Countries, ",")), options.limit())
+}
+
+type sessionTokenKey struct{}
+
+// WithSessionToken makes the PlaceDetails and Coordinates calls made with ctx
+// close the autocomplete session of token, so that its predictions are billed
+// once. Providers without sessions ignore it.
+func WithSessionToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionTokenKey{}, token)
+}
+
+func sessionToken(ctx context.Context) string {
+	token, _ := ctx.Value(sessionTokenKey{}).(string)
+	return token
+}
+
+// MapsProvider resolves places and routes, place ids are only meaningful to
+// the provider that returned them.
+type MapsProvider interface {
+	PlacePredictions(ctx context.Context, input string, options PredictionOptions) ([]Predictions, error)
+	PlaceDetails(ctx context.Context, placeId string) (Place, error)
+	Route(ctx context.Context, origin, destination string) (Route, error)
+	Coordinates(ctx context.Context, placeId string) (Point, error)
+}
+
+// HealthReporter is implemented by providers that know when the api behind
+// them is failing.
+type HealthReporter interface {
+	Degraded() bool
+}
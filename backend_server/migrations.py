@@ -39,11 +39,89 @@ try:
 except:
     print("GeoJSON Index already exists")
 
+# * Rides used a complete boolean before the status lifecycle
+ridesCollection.update_many({"status": {"$exists": False}, "complete": True}, {"$set": {"status": "completed"}, "$unset": {"complete": ""}})
+ridesCollection.update_many({"status": {"$exists": False}}, {"$set": {"status": "scheduled"}, "$unset": {"complete": ""}})
+try:
+    ridesCollection.create_index([("email"), ("status")], name="email_status_index")
+except:
+    print("Rides Status Index already exists")
+
 
 notificationsCollection = db["notifications"]
 try:
     notificationsCollection.create_index("timestamp", name="timestamp_index", expireAfterSeconds=172800)
+    notificationsCollection.create_index([("email", pymongo.ASCENDING), ("_id", pymongo.DESCENDING)], name="email_id_index")
 except:
     print("Notifications Index already exists")
 
+sessionsCollection = db["sessions"]
+try:
+    sessionsCollection.create_index("family_id", name="family_id_index")
+    sessionsCollection.create_index("expires_at", name="expires_at_index", expireAfterSeconds=0)
+except:
+    print("Sessions Index already exists")
+
+
+revokedTokensCollection = db["revoked_tokens"]
+try:
+    revokedTokensCollection.create_index("expires_at", name="expires_at_index", expireAfterSeconds=0)
+except:
+    print("Revoked Tokens Index already exists")
+
+phoneVerificationsCollection = db["phone_verifications"]
+try:
+    phoneVerificationsCollection.create_index([("email"), ("created_at")], name="email_created_at_index")
+    phoneVerificationsCollection.create_index("expires_at", name="expires_at_index", expireAfterSeconds=0)
+except:
+    print("Phone Verifications Index already exists")
+
+deadLettersCollection = db["notification_dead_letters"]
+try:
+    deadLettersCollection.create_index("failed_at", name="failed_at_index")
+except:
+    print("Notification Dead Letters Index already exists")
+
+rideSummariesCollection = db["ride_summaries"]
+try:
+    rideSummariesCollection.create_index("ride_id", unique=True, name="ride_id_index")
+    rideSummariesCollection.create_index("passengers.email", name="passengers_email_index")
+except:
+    print("Ride Summaries Index already exists")
+
+try:
+    ridesCollection.create_index([("recurring_id"), ("timestamp")], unique=True, name="recurring_id_timestamp_index", partialFilterExpression={"recurring_id": {"$exists": True}})
+except:
+    print("Rides Recurring Index already exists")
+
+recurringRidesCollection = db["recurring_rides"]
+try:
+    recurringRidesCollection.create_index("email", name="email_index")
+    recurringRidesCollection.create_index([("active"), ("materialized_until")], name="active_materialized_until_index")
+    recurringRidesCollection.create_index("subscribers.email", name="subscribers_email_index")
+except:
+    print("Recurring Rides Index already exists")
+
+mapsCacheCollection = db["maps_cache"]
+try:
+    mapsCacheCollection.create_index("expires_at", name="expires_at_index", expireAfterSeconds=0)
+except:
+    print("Maps Cache Index already exists")
+
+# * Rides stored the step points as a MultiPoint before the route polyline was kept,
+# * they are joined into a LineString so that they match along the route again.
+# * The segments between steps are straight, updating the route stores the full polyline
+def multipoint_to_linestring(collection):
+    for document in collection.find({"geojson.type": "MultiPoint"}, {"geojson": 1}):
+        coordinates = []
+        for coordinate in document["geojson"]["coordinates"]:
+            if not coordinates or coordinates[-1] != coordinate:
+                coordinates.append(coordinate)
+        if len(coordinates) < 2:
+            continue
+        collection.update_one({"_id": document["_id"]}, {"$set": {"geojson": {"type": "LineString", "coordinates": coordinates}}})
+
+multipoint_to_linestring(ridesCollection)
+multipoint_to_linestring(recurringRidesCollection)
+
 print("Migrations complete")
\ No newline at end of file
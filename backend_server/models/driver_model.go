@@ -13,6 +13,10 @@ type CreateDriverResponse struct {
 	Email      string `bson:"email" json:"email" binding:"required"`
 	Phone      string `bson:"phone" json:"phone" binding:"required"`
 	Name       string `bson:"name" json:"name" binding:"required"`
+	Token      string `bson:"-" json:"token,omitempty"` // access token carrying the driver role
+	// * Average of the ratings given by passengers after completed rides
+	Rating      float64 `bson:"rating" json:"rating"`
+	RatingCount int     `bson:"rating_count" json:"rating_count"`
 }
 
 type UpdateDriverRequest struct {
@@ -20,4 +24,3 @@ type UpdateDriverRequest struct {
 	Seats      int    `bson:"seats" json:"seats" binding:"required"`
 	Experience int    `bson:"experience" json:"experience" binding:"required"`
 }
-
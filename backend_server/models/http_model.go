@@ -2,6 +2,11 @@ package models
 
 type GetPlaceStringReq struct {
 	Place string `uri:"place" binding:"required"`
+	// * Caller position, favours the places around it instead of the campus
+	Lat          *float64 `form:"lat" binding:"required_with=Lng,omitempty,min=-90,max=90"`
+	Lng          *float64 `form:"lng" binding:"required_with=Lat,omitempty,min=-180,max=180"`
+	Radius       float64  `form:"radius" binding:"omitempty,min=100,max=50000"` // meters
+	SessionToken string   `form:"session_token" binding:"max=256"`
 }
 
 type GetPlaceRouteReq struct {
@@ -1,10 +1,127 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationKind is stored as an int and exposed in JSON by its name.
+type NotificationKind int
+
+const (
+	NotificationRideRequested NotificationKind = iota
+	NotificationRequestAccepted
+	NotificationRequestRejected
+	NotificationRideCancelled
+	NotificationRideCompleted
+	NotificationRidePriceIncreased
+	NotificationRideRescheduled
+	NotificationRideRouteChanged
+	NotificationPassengerWithdrew
+	NotificationPassengerRemoved
+	NotificationSubscriptionRequested
+	NotificationSubscriptionAccepted
+	NotificationRecurringRideEnded
+	NotificationSubscriptionCancelled
+	NotificationSubscriptionRejected
+	NotificationSubscriptionRemoved
+)
+
+var notificationKindNames = map[NotificationKind]string{
+	NotificationRideRequested:         "ride_requested",
+	NotificationRequestAccepted:       "request_accepted",
+	NotificationRequestRejected:       "request_rejected",
+	NotificationRideCancelled:         "ride_cancelled",
+	NotificationRideCompleted:         "ride_completed",
+	NotificationRidePriceIncreased:    "ride_price_increased",
+	NotificationRideRescheduled:       "ride_rescheduled",
+	NotificationRideRouteChanged:      "ride_route_changed",
+	NotificationPassengerWithdrew:     "passenger_withdrew",
+	NotificationPassengerRemoved:      "passenger_removed",
+	NotificationSubscriptionRequested: "subscription_requested",
+	NotificationSubscriptionAccepted:  "subscription_accepted",
+	NotificationRecurringRideEnded:    "recurring_ride_ended",
+	NotificationSubscriptionCancelled: "subscription_cancelled",
+	NotificationSubscriptionRejected:  "subscription_rejected",
+	NotificationSubscriptionRemoved:   "subscription_removed",
+}
+
+func (kind NotificationKind) String() string {
+	if name, ok := notificationKindNames[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown_%d", int(kind))
+}
+
+func (kind NotificationKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(kind.String())
+}
+
+// UnmarshalJSON accepts the name as well as the legacy integer value.
+func (kind *NotificationKind) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var value int
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("invalid notification kind %s", data)
+		}
+		*kind = NotificationKind(value)
+		return nil
+	}
+
+	for k, n := range notificationKindNames {
+		if n == name {
+			*kind = k
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown notification kind %s", name)
+}
+
+// NotificationModel stores structured fields only, Title and Content are
+// rendered when the notification is read, in the language of the recipient.
 type NotificationModel struct {
-	Email       string `json:"email" bson:"email"`
-	SenderPhone string `json:"sender_phone" bson:"sender_phone"`
-	SenderName  string `json:"sender_nane" bson:"sender_name"`
-	Type        int    `json:"type" bson:"type"`
-	Content     string `json:"content" bson:"content"`
-	Timestamp   int64  `json:"timestamp" bson:"timestamp"`
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email       string             `json:"email" bson:"email"`
+	SenderPhone string             `json:"sender_phone" bson:"sender_phone"`
+	SenderName  string             `json:"sender_nane" bson:"sender_name"`
+	Type        NotificationKind   `json:"type" bson:"type"`
+	RideID      string             `json:"ride_id,omitempty" bson:"ride_id,omitempty"`
+	RequestID   string             `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	RecurringID string             `json:"recurring_id,omitempty" bson:"recurring_id,omitempty"`
+	DriverName  string             `json:"driver_name,omitempty" bson:"driver_name,omitempty"`
+	Price       int                `json:"price,omitempty" bson:"price,omitempty"`
+	Departure   int64              `json:"departure,omitempty" bson:"departure,omitempty"` // unix seconds
+	Title       string             `json:"title,omitempty" bson:"-"`
+	Content     string             `json:"content" bson:"content,omitempty"` // only set on legacy documents
+	Timestamp   int64              `json:"timestamp" bson:"timestamp"`
+	Read        bool               `json:"read" bson:"read"`
+}
+
+type ListNotificationsReq struct {
+	Cursor string `form:"cursor"` // id of the last notification of the previous page
+	Limit  int64  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Unread bool   `form:"unread"`
+}
+
+type ListNotificationsResp struct {
+	Notifications []NotificationModel `json:"notifications"`
+	NextCursor    string              `json:"next_cursor,omitempty"`
+}
+
+type NotificationIDReq struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// NotificationDeadLetter records a push notification that could not be delivered.
+type NotificationDeadLetter struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Notification NotificationModel  `json:"notification" bson:"notification"`
+	Error        string             `json:"error" bson:"error"`
+	Attempts     int                `json:"attempts" bson:"attempts"`
+	FailedAt     time.Time          `json:"failed_at" bson:"failed_at"`
 }
@@ -0,0 +1,69 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type CreateRecurringRideReq struct {
+	Name          string `json:"name" binding:"required"` // driver name
+	Origin        string `json:"origin" binding:"required"`
+	Destination   string `json:"destination" binding:"required"`
+	Seats         int    `json:"seats" binding:"required"`
+	Price         int    `json:"price" binding:"required"`
+	PlaceId       string `json:"place_id" binding:"required"`
+	Days          []int  `json:"days" binding:"required,min=1,max=7,dive,min=0,max=6"` // 0 is sunday
+	DepartureTime string `json:"departure_time" binding:"required"`                    // HH:MM in the timezone
+	Timezone      string `json:"timezone" binding:"required"`                          // IANA name, e.g. Asia/Kolkata
+	StartDate     string `json:"start_date" binding:"required"`                        // YYYY-MM-DD
+	EndDate       string `json:"end_date"`                                             // YYYY-MM-DD, open ended when empty
+}
+
+// RecurringSubscriber is a passenger riding every occurrence of a recurring ride.
+type RecurringSubscriber struct {
+	Email     string        `json:"email" bson:"email"`
+	Phone     string        `json:"phone" bson:"phone"`
+	Name      string        `json:"name" bson:"name"`
+	Origin    string        `json:"origin" bson:"origin"`
+	OriginLat float64       `json:"origin_lat" bson:"origin_lat"`
+	OriginLng float64       `json:"origin_lng" bson:"origin_lng"`
+	Status    RequestStatus `json:"status" bson:"status"` // pending until the driver accepts
+}
+
+// RecurringRide is a template that concrete rides are created from ahead of time.
+type RecurringRide struct {
+	ID            primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Email         string                `json:"email" bson:"email"`
+	Phone         string                `json:"phone" bson:"phone"`
+	Name          string                `json:"name" bson:"name"`
+	Origin        string                `json:"origin" bson:"origin"`
+	Destination   string                `json:"destination" bson:"destination"`
+	Seats         int                   `json:"seats" bson:"seats"`
+	Price         int                   `json:"price" bson:"price"`
+	PlaceId       string                `json:"place_id" bson:"place_id"`
+	Days          []int                 `json:"days" bson:"days"`
+	DepartureTime string                `json:"departure_time" bson:"departure_time"`
+	Timezone      string                `json:"timezone" bson:"timezone"`
+	StartDate     string                `json:"start_date" bson:"start_date"`
+	EndDate       string                `json:"end_date,omitempty" bson:"end_date,omitempty"`
+	OriginLat     float64               `json:"origin_lat" bson:"origin_lat"`
+	OriginLng     float64               `json:"origin_lng" bson:"origin_lng"`
+	GeoJSON       primitive.M           `json:"geojson" bson:"geojson"`
+	Subscribers   []RecurringSubscriber `json:"subscribers" bson:"subscribers"`
+	Active        bool                  `json:"active" bson:"active"`
+	CreatedAt     int64                 `json:"created_at" bson:"created_at"`
+	// * Rides are created up to this time, unix seconds
+	MaterializedUntil int64 `json:"materialized_until" bson:"materialized_until"`
+}
+
+type RecurringRideIDReq struct {
+	RecurringID string `uri:"recurring_id" binding:"required"`
+}
+
+type RecurringSubscriberReq struct {
+	RecurringID string `uri:"recurring_id" binding:"required"`
+	Email       string `uri:"email" binding:"required,email"`
+}
+
+type SubscribeRecurringRideReq struct {
+	Origin    string  `json:"origin" binding:"required"`
+	OriginLat float64 `json:"origin_lat" binding:"required"`
+	OriginLng float64 `json:"origin_lng" binding:"required"`
+}
@@ -2,6 +2,18 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// RequestStatus is stored as an int to stay compatible with existing requests.
+type RequestStatus int
+
+const (
+	RequestPending RequestStatus = iota
+	RequestAccepted
+	RequestRejected
+	RequestWithdrawn // passenger left the ride after being accepted
+	RequestRemoved   // driver removed the passenger after accepting
+	RequestCancelled // the ride was cancelled
+)
+
 type RequestToDriverReq struct {
 	RideId    string  `json:"ride_id" bson:"_id,omitempty"`
 	Origin    string  `json:"origin"`
@@ -19,7 +31,7 @@ type RequestToDriverRes struct {
 	Email     string             `json:"email"`
 	Phone     string             `json:"phone"`
 	Timestamp int64              `json:"timestamp"`
-	Status    int                `json:"status"` // 0 pending, 1 accepted, 2 rejected
+	Status    RequestStatus      `json:"status"`
 }
 
 type DriverRidesReq struct {
@@ -28,8 +40,8 @@ type DriverRidesReq struct {
 
 type ModifyRideRequestReq struct {
 	RequestID string `json:"request_id" bson:"request_id,omitempty"`
-	RideID string `json:"ride_id" bson:"ride_id,omitempty"`
-	Email  string `json:"email" bson:"email,omitempty"`
+	RideID    string `json:"ride_id" bson:"ride_id,omitempty"`
+	Email     string `json:"email" bson:"email,omitempty"`
 }
 
 type DeleteRequest struct {
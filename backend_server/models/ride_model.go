@@ -1,6 +1,69 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RideStatus is the lifecycle state of a ride, stored by name.
+type RideStatus string
+
+const (
+	RideScheduled  RideStatus = "scheduled"
+	RideBoarding   RideStatus = "boarding"
+	RideInProgress RideStatus = "in_progress"
+	RideCompleted  RideStatus = "completed"
+	RideCancelled  RideStatus = "cancelled"
+)
+
+// * Statuses a ride can move to from each status, completed and cancelled are final
+var rideTransitions = map[RideStatus][]RideStatus{
+	RideScheduled:  {RideBoarding, RideInProgress, RideCancelled},
+	RideBoarding:   {RideInProgress, RideCancelled},
+	RideInProgress: {RideCompleted},
+}
+
+// ActiveRideStatuses are the statuses of a ride that has not ended yet.
+var ActiveRideStatuses = []RideStatus{RideScheduled, RideBoarding, RideInProgress}
+
+// RideOverlapWindow is how close two rides of the same driver or passenger can depart.
+const RideOverlapWindow = time.Hour
+
+func (status RideStatus) CanTransitionTo(next RideStatus) bool {
+	for _, allowed := range rideTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviousStatuses returns the statuses a ride can be in to move to status.
+func (status RideStatus) PreviousStatuses() []RideStatus {
+	var previous []RideStatus
+	for from := range rideTransitions {
+		if from.CanTransitionTo(status) {
+			previous = append(previous, from)
+		}
+	}
+	return previous
+}
+
+// TimestampField is the ride field recording when the ride entered status.
+func (status RideStatus) TimestampField() string {
+	switch status {
+	case RideBoarding:
+		return "boarding_at"
+	case RideInProgress:
+		return "started_at"
+	case RideCompleted:
+		return "completed_at"
+	case RideCancelled:
+		return "cancelled_at"
+	}
+	return ""
+}
 
 type CreateRideReq struct {
 	Name        string `json:"name" binding:"required"` // driver name
@@ -20,19 +83,82 @@ type Passenger struct {
 	Origin    string  `json:"origin" binding:"required"`
 	OriginLat float64 `json:"origin_lat" binding:"required"`
 	OriginLng float64 `json:"origin_lng" binding:"required"`
+	// * Set when the driver raised the price after the passenger was accepted
+	PendingConfirmation bool `json:"pending_confirmation,omitempty" bson:"pending_confirmation,omitempty"`
+	// * Set when the driver rescheduled or rerouted the ride after the passenger was accepted
+	RideChanged bool `json:"ride_changed,omitempty" bson:"ride_changed,omitempty"`
 }
 
+// UpdateRideReq only changes the fields that are present in the request.
 type UpdateRideReq struct {
-	Price     int   `json:"price" binding:"required"`
-	Timestamp int64 `json:"timestamp" binding:"required"`
+	Origin      *string `json:"origin" binding:"omitempty,min=1"`
+	Destination *string `json:"destination" binding:"omitempty,min=1"`
+	PlaceId     *string `json:"place_id" binding:"omitempty,min=1"`
+	Seats       *int    `json:"seats" binding:"omitempty,min=1"`
+	Price       *int    `json:"price" binding:"omitempty,min=1"`
+	Timestamp   *int64  `json:"timestamp" binding:"omitempty,min=1"`
 }
 
-type CompleteRideReq struct {
-	Complete bool `json:"complete" binding:"required"`
+type RideIDReq struct {
+	RideID string `uri:"ride_id" binding:"required"`
 }
 
+type RemovePassengerReq struct {
+	RideID string `uri:"ride_id" binding:"required"`
+	Email  string `uri:"email" binding:"required,email"`
+}
+
+type CancelRideReq struct {
+	Reason string `form:"reason" binding:"max=500"`
+}
+
+type SearchRidePlaceReq struct {
+	Origin       string `uri:"place_id" binding:"required"`
+	SessionToken string `form:"session_token" binding:"max=256"` // autocomplete session the place id came from
+}
+
+type SearchRideCoordinatesReq struct {
+	Lat *float64 `form:"lat" binding:"required,min=-90,max=90"`
+	Lng *float64 `form:"lng" binding:"required,min=-180,max=180"`
+}
+
+// SearchRideReq holds the filters shared by both ways of searching.
 type SearchRideReq struct {
-	Origin string `uri:"place_id" binding:"required"`
+	Destination string  `form:"destination"`                                  // place id, only rides heading there when set
+	Radius      float64 `form:"radius" binding:"omitempty,min=100,max=50000"` // meters, 5000 when empty
+	// * Unix seconds, rides that already left are never returned
+	DepartureAfter  int64   `form:"departure_after" binding:"omitempty,min=0"`
+	DepartureBefore int64   `form:"departure_before" binding:"omitempty,min=0"`
+	MaxPrice        int     `form:"max_price" binding:"omitempty,min=1"`
+	MinSeats        int     `form:"min_seats" binding:"omitempty,min=1"` // free seats, 1 when empty
+	MinRating       float64 `form:"min_rating" binding:"omitempty,min=0,max=5"`
+	Sort            string  `form:"sort" binding:"omitempty,oneof=distance time price"` // distance from the route when empty
+	Cursor          string  `form:"cursor"`                                             // next_cursor of the previous page
+	Limit           int     `form:"limit" binding:"omitempty,min=1,max=100"`
+	// * Autocomplete session the destination came from
+	DestinationSessionToken string `form:"destination_session_token" binding:"max=256"`
+}
+
+// SearchRideResult is a ride along with where and how far off its route the
+// passenger would be picked up.
+type SearchRideResult struct {
+	CreateRideResp    `bson:",inline"`
+	Distance          float64 `json:"distance" bson:"distance"` // meters from the route to the passenger
+	DriverRating      float64 `json:"driver_rating" bson:"driver_rating"`
+	DriverRatingCount int     `json:"driver_rating_count" bson:"driver_rating_count"` // 0 for drivers nobody rated yet
+	Detour            float64 `json:"detour" bson:"-"`                                // extra meters driven to pick the passenger up
+	PickupLat         float64 `json:"pickup_lat" bson:"-"`
+	PickupLng         float64 `json:"pickup_lng" bson:"-"`
+	// * Only set when searching with a destination
+	DropoffLat float64 `json:"dropoff_lat,omitempty" bson:"-"`
+	DropoffLng float64 `json:"dropoff_lng,omitempty" bson:"-"`
+	// * Meters from the passenger to the pickup, plus from the drop-off to the destination
+	WalkingDistance float64 `json:"walking_distance" bson:"-"`
+}
+
+type SearchRideResp struct {
+	Rides      []SearchRideResult `json:"rides"`
+	NextCursor string             `json:"next_cursor,omitempty"`
 }
 
 type CreateRideResp struct {
@@ -46,6 +172,14 @@ type CreateRideResp struct {
 	Phone       string      `json:"phone" binding:"required"`
 	Timestamp   int64       `json:"timestamp" binding:"required"`
 	Passengers  []Passenger `json:"passengers" binding:"required"`
-	Complete    bool        `json:"complete"`
+	Status      RideStatus  `json:"status" bson:"status"`
+	// * Unix seconds of each transition, zero until the ride reaches the status
+	BoardingAt   int64  `json:"boarding_at,omitempty" bson:"boarding_at,omitempty"`
+	StartedAt    int64  `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt  int64  `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CancelledAt  int64  `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+	CancelReason string `json:"cancel_reason,omitempty" bson:"cancel_reason,omitempty"`
+	// * Set on rides created from a recurring ride
+	RecurringID string      `json:"recurring_id,omitempty" bson:"recurring_id,omitempty"`
 	GeoJSON     primitive.M `json:"geojson"`
 }
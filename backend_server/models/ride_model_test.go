@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRideStatusTransitions(t *testing.T) {
+	require.True(t, RideScheduled.CanTransitionTo(RideBoarding))
+	require.True(t, RideScheduled.CanTransitionTo(RideInProgress))
+	require.True(t, RideBoarding.CanTransitionTo(RideCancelled))
+	require.True(t, RideInProgress.CanTransitionTo(RideCompleted))
+
+	// * A ride that already left cannot be cancelled and ended rides are final
+	require.False(t, RideInProgress.CanTransitionTo(RideCancelled))
+	require.False(t, RideScheduled.CanTransitionTo(RideCompleted))
+	require.False(t, RideCompleted.CanTransitionTo(RideScheduled))
+	require.False(t, RideCancelled.CanTransitionTo(RideBoarding))
+}
+
+func TestRideStatusPreviousStatuses(t *testing.T) {
+	require.ElementsMatch(t, []RideStatus{RideScheduled, RideBoarding}, RideCancelled.PreviousStatuses())
+	require.ElementsMatch(t, []RideStatus{RideInProgress}, RideCompleted.PreviousStatuses())
+	require.Empty(t, RideScheduled.PreviousStatuses())
+}
+
+func TestRideStatusTimestampField(t *testing.T) {
+	for _, status := range []RideStatus{RideBoarding, RideInProgress, RideCompleted, RideCancelled} {
+		require.NotEmpty(t, status.TimestampField())
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID        string    `json:"id" bson:"_id"` // sha256 of the refresh token
+	FamilyID  string    `json:"family_id" bson:"family_id"`
+	Email     string    `json:"email" bson:"email"`
+	UserAgent string    `json:"user_agent" bson:"user_agent"`
+	ClientIp  string    `json:"client_ip" bson:"client_ip"`
+	IsUsed    bool      `json:"is_used" bson:"is_used"`
+	IsRevoked bool      `json:"is_revoked" bson:"is_revoked"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+type RevokedToken struct {
+	ID        string    `json:"id" bson:"_id"` // token payload id
+	Email     string    `json:"email" bson:"email"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RefreshTokenResponse struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
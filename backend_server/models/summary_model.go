@@ -0,0 +1,37 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type PassengerSummary struct {
+	Email     string  `json:"email" bson:"email"`
+	Name      string  `json:"name" bson:"name"`
+	Origin    string  `json:"origin" bson:"origin"`
+	Distance  float64 `json:"distance" bson:"distance"`                 // meters travelled from the pickup
+	FareShare int     `json:"fare_share" bson:"fare_share"`             // price prorated by distance
+	Duration  int64   `json:"duration" bson:"duration"`                 // seconds, prorated by distance
+	Rating    int     `json:"rating,omitempty" bson:"rating,omitempty"` // given to the driver, 1 to 5
+}
+
+// RideSummary is computed once when a ride is completed.
+type RideSummary struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RideID      string             `json:"ride_id" bson:"ride_id"`
+	DriverEmail string             `json:"driver_email" bson:"driver_email"`
+	DriverName  string             `json:"driver_name" bson:"driver_name"`
+	Origin      string             `json:"origin" bson:"origin"`
+	Destination string             `json:"destination" bson:"destination"`
+	Price       int                `json:"price" bson:"price"`
+	DepartedAt  int64              `json:"departed_at" bson:"departed_at"`
+	CompletedAt int64              `json:"completed_at" bson:"completed_at"`
+	Distance    float64            `json:"distance" bson:"distance"` // meters
+	Duration    int64              `json:"duration" bson:"duration"` // seconds
+	Passengers  []PassengerSummary `json:"passengers" bson:"passengers"`
+}
+
+type RideSummaryReq struct {
+	RideID string `uri:"ride_id" binding:"required"`
+}
+
+type RateRideReq struct {
+	Rating int `json:"rating" binding:"required,min=1,max=5"`
+}
@@ -8,7 +8,11 @@ type CreatePassengerRequest struct {
 	City       string `bson:"city" json:"city" binding:"required"`
 	State      string `bson:"state" json:"state" binding:"required"`
 	Pincode    string `bson:"pincode" json:"pincode" binding:"required,max=6,min=6"`
-	FirebaseID string `bson:"firebase_id,unique" json:"firebase_id" binding:"required"`
+	FirebaseID string `bson:"firebase_id,unique" json:"firebase_id"` // taken from the id token when empty
+	IDToken    string `bson:"-" json:"id_token" binding:"required"`  // firebase id token
+	// * Device registration token used for push notifications
+	DeviceToken string `bson:"device_token,omitempty" json:"device_token,omitempty"`
+	Language    string `bson:"language,omitempty" json:"language,omitempty" binding:"omitempty,oneof=en hi"`
 }
 
 type CreatePassengerResponse struct {
@@ -21,6 +25,12 @@ type CreatePassengerResponse struct {
 	Pincode    string `bson:"pincode" json:"pincode" binding:"required,max=6,min=6"`
 	FirebaseID string `bson:"firebase_id,unique" json:"firebase_id" binding:"required"`
 	Token      string `bson:"token,unique" json:"token"`
+	// * Reset whenever the phone changes, required to offer or request rides
+	PhoneVerified bool   `bson:"phone_verified" json:"phone_verified"`
+	DeviceToken   string `bson:"device_token,omitempty" json:"device_token,omitempty"`
+	Language      string `bson:"language,omitempty" json:"language,omitempty"` // preferred language for notifications
+	// * Only returned on signup and login, the hash is kept in the sessions collection
+	RefreshToken string `bson:"-" json:"refresh_token,omitempty"`
 }
 
 type UpdatePassengerRequest struct {
@@ -31,4 +41,11 @@ type UpdatePassengerRequest struct {
 	State   string `bson:"state" json:"state" binding:"required"`
 	Pincode string `bson:"pincode" json:"pincode" binding:"required,max=6,min=6"`
 	Token   string `bson:"token" json:"token"`
+	// * Kept as is when empty
+	DeviceToken string `bson:"device_token,omitempty" json:"device_token,omitempty"`
+	Language    string `bson:"language,omitempty" json:"language,omitempty" binding:"omitempty,oneof=en hi"`
+}
+
+type LoginPassengerRequest struct {
+	IDToken string `json:"id_token" binding:"required"` // firebase id token
 }
@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+)
+
+const sendTimeout = 10 * time.Second
+
+var (
+	errQueueFull         = errors.New("push queue is full")
+	errDispatcherStopped = errors.New("push dispatcher stopped before delivery")
+)
+
+type DispatcherConfig struct {
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	Backoff     time.Duration // doubled after every failed attempt
+}
+
+func DefaultDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		Workers:     4,
+		QueueSize:   1024,
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+	}
+}
+
+// Renderer turns a notification into its localized title and body.
+type Renderer interface {
+	Render(language string, notification models.NotificationModel) (string, string, error)
+}
+
+// Dispatcher sends notifications as push messages in the background, retrying
+// failed deliveries and recording a dead letter once it gives up.
+type Dispatcher struct {
+	sender   Sender
+	store    Store
+	renderer Renderer
+	config   DispatcherConfig
+
+	queue    chan models.NotificationModel
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	closed   bool
+	stop     chan struct{} // closed once Close gives up waiting
+	stopOnce sync.Once
+}
+
+func NewDispatcher(sender Sender, store Store, renderer Renderer, config DispatcherConfig) *Dispatcher {
+	dispatcher := &Dispatcher{
+		sender:   sender,
+		store:    store,
+		renderer: renderer,
+		config:   config,
+		queue:    make(chan models.NotificationModel, config.QueueSize),
+		stop:     make(chan struct{}),
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		dispatcher.wg.Add(1)
+		go dispatcher.work()
+	}
+
+	return dispatcher
+}
+
+// Dispatch queues notifications without blocking the caller.
+func (dispatcher *Dispatcher) Dispatch(notifications ...models.NotificationModel) {
+	dispatcher.mu.RLock()
+	defer dispatcher.mu.RUnlock()
+
+	if dispatcher.closed {
+		return
+	}
+
+	var dropped []models.NotificationModel
+	for _, notification := range notifications {
+		select {
+		case dispatcher.queue <- notification:
+		default:
+			dropped = append(dropped, notification)
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+
+	// * Saving dead letters hits the database, callers are not kept waiting.
+	// * Added under the lock so that Close waits for them
+	dispatcher.wg.Add(1)
+	go func() {
+		defer dispatcher.wg.Done()
+
+		for _, notification := range dropped {
+			dispatcher.deadLetter(notification, errQueueFull, 0)
+		}
+	}()
+}
+
+// Close stops accepting notifications and waits for the queued ones. Once ctx
+// is done the workers stop retrying and the notifications still queued are
+// saved as dead letters.
+func (dispatcher *Dispatcher) Close(ctx context.Context) error {
+	dispatcher.mu.Lock()
+	if !dispatcher.closed {
+		dispatcher.closed = true
+		close(dispatcher.queue)
+	}
+	dispatcher.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	dispatcher.stopOnce.Do(func() { close(dispatcher.stop) })
+	<-done
+
+	for notification := range dispatcher.queue {
+		dispatcher.deadLetter(notification, errDispatcherStopped, 0)
+	}
+
+	return ctx.Err()
+}
+
+func (dispatcher *Dispatcher) work() {
+	defer dispatcher.wg.Done()
+
+	for notification := range dispatcher.queue {
+		select {
+		case <-dispatcher.stop:
+			// * Close saves the rest of the queue as dead letters
+			dispatcher.deadLetter(notification, errDispatcherStopped, 0)
+			return
+		default:
+		}
+
+		dispatcher.deliver(notification)
+	}
+}
+
+func (dispatcher *Dispatcher) deliver(notification models.NotificationModel) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	recipient, err := dispatcher.store.Recipient(ctx, notification.Email)
+	cancel()
+
+	if err != nil {
+		dispatcher.deadLetter(notification, err, 0)
+		return
+	}
+
+	// * Nothing to do for users that never registered a device
+	if recipient.DeviceToken == "" {
+		return
+	}
+
+	title, body, err := dispatcher.renderer.Render(recipient.Language, notification)
+	if err != nil {
+		dispatcher.deadLetter(notification, err, 0)
+		return
+	}
+
+	msg := Message{
+		DeviceToken: recipient.DeviceToken,
+		Title:       title,
+		Body:        body,
+		Data: map[string]string{
+			"id":   notification.ID.Hex(),
+			"type": notification.Type.String(),
+		},
+	}
+
+	backoff := dispatcher.config.Backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = dispatcher.sender.Send(ctx, msg)
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		if errors.Is(err, ErrInvalidDeviceToken) || attempt >= dispatcher.config.MaxAttempts {
+			dispatcher.deadLetter(notification, err, attempt)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-dispatcher.stop:
+			dispatcher.deadLetter(notification, err, attempt)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+func (dispatcher *Dispatcher) deadLetter(notification models.NotificationModel, err error, attempts int) {
+	deadLetter := models.NotificationDeadLetter{
+		Notification: notification,
+		Error:        err.Error(),
+		Attempts:     attempts,
+		FailedAt:     time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if err := dispatcher.store.SaveDeadLetter(ctx, deadLetter); err != nil {
+		log.Printf("cannot save dead letter for notification %s : %v", notification.ID.Hex(), err)
+	}
+}
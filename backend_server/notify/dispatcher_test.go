@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/templates"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryStore struct {
+	mu          sync.Mutex
+	devices     map[string]string
+	deadLetters []models.NotificationDeadLetter
+}
+
+func (store *memoryStore) Recipient(ctx context.Context, email string) (Recipient, error) {
+	return Recipient{DeviceToken: store.devices[email], Language: "hi"}, nil
+}
+
+func (store *memoryStore) SaveDeadLetter(ctx context.Context, deadLetter models.NotificationDeadLetter) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deadLetters = append(store.deadLetters, deadLetter)
+	return nil
+}
+
+func testDispatcher(t *testing.T, sender Sender, store Store) *Dispatcher {
+	registry, err := templates.DefaultRegistry()
+	require.NoError(t, err)
+
+	return NewDispatcher(sender, store, registry, DispatcherConfig{
+		Workers:     2,
+		QueueSize:   10,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	})
+}
+
+func randomNotification(email string) models.NotificationModel {
+	return models.NotificationModel{
+		ID:         primitive.NewObjectID(),
+		Email:      email,
+		Type:       models.NotificationRequestAccepted,
+		DriverName: utils.RandomString(8),
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	email := utils.RandomEmail()
+	store := &memoryStore{devices: map[string]string{email: "device-1"}}
+	sender := &FakeSender{}
+
+	dispatcher := testDispatcher(t, sender, store)
+	notification := randomNotification(email)
+
+	// * Users without a device are skipped
+	dispatcher.Dispatch(notification, randomNotification(utils.RandomEmail()))
+	dispatcher.Close(context.Background())
+
+	sent := sender.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, "device-1", sent[0].DeviceToken)
+	require.Equal(t, "सवारी अनुरोध स्वीकार", sent[0].Title)
+	require.Contains(t, sent[0].Body, notification.DriverName)
+	require.Equal(t, notification.ID.Hex(), sent[0].Data["id"])
+	require.Equal(t, "request_accepted", sent[0].Data["type"])
+	require.Empty(t, store.deadLetters)
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	email := utils.RandomEmail()
+	store := &memoryStore{devices: map[string]string{email: "device-1"}}
+	sender := &FakeSender{Errors: []error{errors.New("unavailable"), errors.New("unavailable")}}
+
+	dispatcher := testDispatcher(t, sender, store)
+	dispatcher.Dispatch(randomNotification(email))
+	dispatcher.Close(context.Background())
+
+	require.Equal(t, 3, sender.Attempts)
+	require.Len(t, sender.Sent(), 1)
+	require.Empty(t, store.deadLetters)
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	email := utils.RandomEmail()
+	store := &memoryStore{devices: map[string]string{email: "device-1"}}
+	sender := &FakeSender{Errors: []error{errors.New("unavailable"), errors.New("unavailable"), errors.New("unavailable")}}
+
+	dispatcher := testDispatcher(t, sender, store)
+	notification := randomNotification(email)
+	dispatcher.Dispatch(notification)
+	dispatcher.Close(context.Background())
+
+	require.Equal(t, 3, sender.Attempts)
+	require.Empty(t, sender.Sent())
+	require.Len(t, store.deadLetters, 1)
+	require.Equal(t, notification.ID, store.deadLetters[0].Notification.ID)
+	require.Equal(t, 3, store.deadLetters[0].Attempts)
+}
+
+func TestDispatcherInvalidDeviceToken(t *testing.T) {
+	email := utils.RandomEmail()
+	store := &memoryStore{devices: map[string]string{email: "device-1"}}
+	sender := &FakeSender{Errors: []error{ErrInvalidDeviceToken}}
+
+	dispatcher := testDispatcher(t, sender, store)
+	dispatcher.Dispatch(randomNotification(email))
+	dispatcher.Close(context.Background())
+
+	require.Equal(t, 1, sender.Attempts)
+	require.Len(t, store.deadLetters, 1)
+}
+
+// blockingStore holds dead letters until release is closed.
+type blockingStore struct {
+	memoryStore
+	release chan struct{}
+}
+
+func (store *blockingStore) SaveDeadLetter(ctx context.Context, deadLetter models.NotificationDeadLetter) error {
+	<-store.release
+	return store.memoryStore.SaveDeadLetter(ctx, deadLetter)
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	registry, err := templates.DefaultRegistry()
+	require.NoError(t, err)
+
+	store := &blockingStore{release: make(chan struct{})}
+
+	// * Without workers the second notification does not fit in the queue
+	dispatcher := NewDispatcher(&FakeSender{}, store, registry, DispatcherConfig{QueueSize: 1, MaxAttempts: 1})
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Dispatch(randomNotification(utils.RandomEmail()), randomNotification(utils.RandomEmail()))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch waited for the dead letter")
+	}
+
+	close(store.release)
+	dispatcher.Close(context.Background())
+
+	require.Len(t, store.deadLetters, 1)
+	require.Equal(t, errQueueFull.Error(), store.deadLetters[0].Error)
+}
+
+func TestDispatcherCloseTimeout(t *testing.T) {
+	registry, err := templates.DefaultRegistry()
+	require.NoError(t, err)
+
+	email := utils.RandomEmail()
+	store := &memoryStore{devices: map[string]string{email: "device-1"}}
+	sender := &FakeSender{Errors: []error{errors.New("unavailable")}}
+
+	// * The worker waits for its retry while the second notification is queued
+	dispatcher := NewDispatcher(sender, store, registry, DispatcherConfig{
+		Workers:     1,
+		QueueSize:   10,
+		MaxAttempts: 3,
+		Backoff:     time.Hour,
+	})
+	first, second := randomNotification(email), randomNotification(email)
+	dispatcher.Dispatch(first, second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, dispatcher.Close(ctx), context.DeadlineExceeded)
+
+	require.Equal(t, 1, sender.Attempts)
+	require.Empty(t, sender.Sent())
+	require.Len(t, store.deadLetters, 2)
+	require.Equal(t, first.ID, store.deadLetters[0].Notification.ID)
+	require.Equal(t, 1, store.deadLetters[0].Attempts)
+	require.Equal(t, second.ID, store.deadLetters[1].Notification.ID)
+	require.Equal(t, errDispatcherStopped.Error(), store.deadLetters[1].Error)
+}
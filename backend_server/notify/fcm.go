@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+)
+
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMSender sends messages through the Firebase Cloud Messaging HTTP v1 API,
+// authenticated with a service account.
+type FCMSender struct {
+	projectID string
+	account   serviceAccount
+	client    *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMSender(projectID string, credentialsFile string) (*FCMSender, error) {
+	if projectID == "" {
+		return nil, errors.New("fcm project id is empty")
+	}
+
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fcm credentials : %v", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal fcm credentials : %v", err)
+	}
+
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	sender := &FCMSender{
+		projectID: projectID,
+		account:   account,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+
+	return sender, nil
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed service
+// account assertion for a new one when it is about to expire.
+func (sender *FCMSender) token(ctx context.Context) (string, error) {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	if sender.accessToken != "" && time.Now().Add(time.Minute).Before(sender.expiresAt) {
+		return sender.accessToken, nil
+	}
+
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sender.account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("cannot parse fcm private key : %v", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   sender.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   sender.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sender.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cannot get fcm access token : %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("cannot get fcm access token : status %d : %s", resp.StatusCode, body)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("cannot unmarshal response body : %v", err)
+	}
+
+	sender.accessToken = tokenResp.AccessToken
+	sender.expiresAt = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+
+	return sender.accessToken, nil
+}
+
+func (sender *FCMSender) Send(ctx context.Context, msg Message) error {
+	accessToken, err := sender.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": msg.DeviceToken,
+			"notification": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Body,
+			},
+			"data": msg.Data,
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(fcmEndpoint, sender.projectID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send fcm message : %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	// * The app was uninstalled or the token rotated, retrying will not help
+	if resp.StatusCode == http.StatusNotFound || bytes.Contains(respBody, []byte("UNREGISTERED")) || bytes.Contains(respBody, []byte("INVALID_ARGUMENT")) {
+		return fmt.Errorf("%w : %s", ErrInvalidDeviceToken, respBody)
+	}
+
+	return fmt.Errorf("cannot send fcm message : status %d : %s", resp.StatusCode, respBody)
+}
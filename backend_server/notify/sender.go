@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrInvalidDeviceToken is returned for device tokens the provider will never
+// accept again, such messages are not retried.
+var ErrInvalidDeviceToken = errors.New("invalid device token")
+
+type Message struct {
+	DeviceToken string            `json:"device_token"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Data        map[string]string `json:"data,omitempty"`
+}
+
+// Sender delivers a push message to a single device.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender only writes messages to the server log, for local development.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("push to %s : %s - %s", msg.DeviceToken, msg.Title, msg.Body)
+	return nil
+}
+
+// FakeSender records the messages it is given, Errors are returned in order
+// before messages start succeeding.
+type FakeSender struct {
+	mu       sync.Mutex
+	Errors   []error
+	Attempts int
+	Messages []Message
+}
+
+func (sender *FakeSender) Send(ctx context.Context, msg Message) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	sender.Attempts++
+	if len(sender.Errors) > 0 {
+		err := sender.Errors[0]
+		sender.Errors = sender.Errors[1:]
+		return err
+	}
+
+	sender.Messages = append(sender.Messages, msg)
+	return nil
+}
+
+func (sender *FakeSender) Sent() []Message {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	return append([]Message(nil), sender.Messages...)
+}
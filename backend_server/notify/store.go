@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Recipient struct {
+	DeviceToken string
+	Language    string
+}
+
+// Store resolves recipients to devices and keeps undeliverable notifications.
+type Store interface {
+	Recipient(ctx context.Context, email string) (Recipient, error)
+	SaveDeadLetter(ctx context.Context, deadLetter models.NotificationDeadLetter) error
+}
+
+type MongoStore struct {
+	passengers  *mongo.Collection
+	deadLetters *mongo.Collection
+}
+
+func NewMongoStore(passengers *mongo.Collection, deadLetters *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		passengers:  passengers,
+		deadLetters: deadLetters,
+	}
+}
+
+// Recipient has an empty device token for users without a registered device.
+func (store *MongoStore) Recipient(ctx context.Context, email string) (Recipient, error) {
+	var passenger models.CreatePassengerResponse
+
+	err := store.passengers.FindOne(ctx, bson.M{"email": email}).Decode(&passenger)
+	if err == mongo.ErrNoDocuments {
+		return Recipient{}, nil
+	}
+
+	recipient := Recipient{
+		DeviceToken: passenger.DeviceToken,
+		Language:    passenger.Language,
+	}
+
+	return recipient, err
+}
+
+func (store *MongoStore) SaveDeadLetter(ctx context.Context, deadLetter models.NotificationDeadLetter) error {
+	_, err := store.deadLetters.InsertOne(ctx, deadLetter)
+	return err
+}
@@ -0,0 +1,110 @@
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+)
+
+// Hub fans notifications out to the live subscribers of each recipient.
+// Publishing never blocks, a subscriber that falls behind is closed and is
+// expected to reconnect and resume from the last event it received.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+	bufferSize  int
+}
+
+type Subscription struct {
+	C <-chan models.NotificationModel
+
+	email string
+	ch    chan models.NotificationModel
+	once  sync.Once
+}
+
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (hub *Hub) Subscribe(email string) *Subscription {
+	ch := make(chan models.NotificationModel, hub.bufferSize)
+	sub := &Subscription{C: ch, email: email, ch: ch}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.subscribers[email] == nil {
+		hub.subscribers[email] = make(map[*Subscription]struct{})
+	}
+	hub.subscribers[email][sub] = struct{}{}
+
+	return sub
+}
+
+func (hub *Hub) Unsubscribe(sub *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.remove(sub)
+}
+
+// remove must be called with the write lock held.
+func (hub *Hub) remove(sub *Subscription) {
+	if subs, ok := hub.subscribers[sub.email]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(hub.subscribers, sub.email)
+		}
+	}
+
+	sub.once.Do(func() { close(sub.ch) })
+}
+
+func (hub *Hub) Publish(notifications ...models.NotificationModel) {
+	lagging := make(map[*Subscription]bool)
+
+	hub.mu.RLock()
+	for _, notification := range notifications {
+		for sub := range hub.subscribers[notification.Email] {
+			// * Nothing after a dropped notification, the stream would have a gap
+			if lagging[sub] {
+				continue
+			}
+
+			select {
+			case sub.ch <- notification:
+			default:
+				lagging[sub] = true
+			}
+		}
+	}
+	hub.mu.RUnlock()
+
+	if len(lagging) == 0 {
+		return
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range lagging {
+		hub.remove(sub)
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (hub *Hub) Subscribers() int {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+
+	count := 0
+	for _, subs := range hub.subscribers {
+		count += len(subs)
+	}
+
+	return count
+}
@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub(10)
+
+	email := utils.RandomEmail()
+	sub1 := hub.Subscribe(email)
+	sub2 := hub.Subscribe(email)
+	other := hub.Subscribe(utils.RandomEmail())
+	require.Equal(t, 3, hub.Subscribers())
+
+	notification := models.NotificationModel{Email: email, Content: utils.RandomString(10)}
+	hub.Publish(notification)
+
+	require.Equal(t, notification, <-sub1.C)
+	require.Equal(t, notification, <-sub2.C)
+	require.Len(t, other.C, 0)
+
+	hub.Unsubscribe(sub1)
+	hub.Unsubscribe(sub1)
+	require.Equal(t, 2, hub.Subscribers())
+
+	_, ok := <-sub1.C
+	require.False(t, ok)
+}
+
+func TestHubDropsLaggingSubscriber(t *testing.T) {
+	hub := NewHub(1)
+
+	email := utils.RandomEmail()
+	sub := hub.Subscribe(email)
+
+	hub.Publish(models.NotificationModel{Email: email}, models.NotificationModel{Email: email})
+	require.Equal(t, 0, hub.Subscribers())
+
+	// * The buffered notification is still delivered before the channel closes
+	_, ok := <-sub.C
+	require.True(t, ok)
+	_, ok = <-sub.C
+	require.False(t, ok)
+}
+
+func TestHubConcurrentSubscribers(t *testing.T) {
+	hub := NewHub(100)
+	email := utils.RandomEmail()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sub := hub.Subscribe(email)
+			defer hub.Unsubscribe(sub)
+
+			hub.Publish(models.NotificationModel{Email: email})
+			<-sub.C
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 0, hub.Subscribers())
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // timezones do not depend on the host
+
+	"github.com/achintya-7/car_pooling_backend/models"
+)
+
+const dateLayout = "2006-01-02"
+
+type rule struct {
+	location *time.Location
+	days     map[time.Weekday]bool
+	hour     int
+	minute   int
+	start    time.Time
+	end      time.Time // exclusive, zero when open ended
+}
+
+func parseRule(template models.RecurringRide) (rule, error) {
+	var r rule
+
+	location, err := time.LoadLocation(template.Timezone)
+	if err != nil {
+		return r, fmt.Errorf("invalid timezone %s : %v", template.Timezone, err)
+	}
+	r.location = location
+
+	departure, err := time.Parse("15:04", template.DepartureTime)
+	if err != nil {
+		return r, fmt.Errorf("invalid departure time %s : %v", template.DepartureTime, err)
+	}
+	r.hour, r.minute = departure.Hour(), departure.Minute()
+
+	if len(template.Days) == 0 {
+		return r, fmt.Errorf("no days of week")
+	}
+	r.days = make(map[time.Weekday]bool)
+	for _, day := range template.Days {
+		if day < 0 || day > 6 {
+			return r, fmt.Errorf("invalid day of week %d", day)
+		}
+		r.days[time.Weekday(day)] = true
+	}
+
+	r.start, err = time.ParseInLocation(dateLayout, template.StartDate, location)
+	if err != nil {
+		return r, fmt.Errorf("invalid start date %s : %v", template.StartDate, err)
+	}
+
+	if template.EndDate != "" {
+		end, err := time.ParseInLocation(dateLayout, template.EndDate, location)
+		if err != nil {
+			return r, fmt.Errorf("invalid end date %s : %v", template.EndDate, err)
+		}
+		if end.Before(r.start) {
+			return r, fmt.Errorf("end date is before start date")
+		}
+		// * The end date itself is included
+		r.end = end.AddDate(0, 0, 1)
+	}
+
+	return r, nil
+}
+
+// Validate checks that the schedule of a recurring ride can be parsed.
+func Validate(template models.RecurringRide) error {
+	_, err := parseRule(template)
+	return err
+}
+
+// Ended reports whether the end date of a recurring ride is over at now, open
+// ended rides never end.
+func Ended(template models.RecurringRide, now time.Time) (bool, error) {
+	r, err := parseRule(template)
+	if err != nil {
+		return false, err
+	}
+	return !r.end.IsZero() && !now.Before(r.end), nil
+}
+
+// Occurrences returns the departures of a recurring ride after from and up to
+// to, in the timezone of the ride so that the local departure time stays the
+// same across daylight saving changes.
+func Occurrences(template models.RecurringRide, from, to time.Time) ([]time.Time, error) {
+	r, err := parseRule(template)
+	if err != nil {
+		return nil, err
+	}
+
+	var departures []time.Time
+
+	local := from.In(r.location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, r.location)
+
+	for !day.After(to) {
+		departure := time.Date(day.Year(), day.Month(), day.Day(), r.hour, r.minute, 0, 0, r.location)
+		day = day.AddDate(0, 0, 1)
+
+		if !r.days[departure.Weekday()] || departure.Before(r.start) {
+			continue
+		}
+		if !r.end.IsZero() && !departure.Before(r.end) {
+			break
+		}
+		if departure.After(from) && !departure.After(to) {
+			departures = append(departures, departure)
+		}
+	}
+
+	return departures, nil
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func weekdayCommute() models.RecurringRide {
+	return models.RecurringRide{
+		ID:            primitive.NewObjectID(),
+		Email:         "driver@example.com",
+		Seats:         3,
+		Days:          []int{1, 2, 3, 4, 5},
+		DepartureTime: "08:30",
+		Timezone:      "Asia/Kolkata",
+		StartDate:     "2023-01-02",
+		EndDate:       "2023-01-13",
+	}
+}
+
+func TestOccurrencesWeekdays(t *testing.T) {
+	template := weekdayCommute()
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// * Sunday to the next sunday covers one working week
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, kolkata)
+	departures, err := Occurrences(template, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, departures, 5)
+
+	for _, departure := range departures {
+		require.Equal(t, 8, departure.Hour())
+		require.Equal(t, 30, departure.Minute())
+		require.NotEqual(t, time.Saturday, departure.Weekday())
+		require.NotEqual(t, time.Sunday, departure.Weekday())
+	}
+}
+
+func TestOccurrencesBounds(t *testing.T) {
+	template := weekdayCommute()
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// * The end date is included, nothing is created after it
+	departures, err := Occurrences(template, time.Date(2023, 1, 1, 0, 0, 0, 0, kolkata), time.Date(2023, 2, 1, 0, 0, 0, 0, kolkata))
+	require.NoError(t, err)
+	require.Len(t, departures, 10)
+	require.Equal(t, 13, departures[len(departures)-1].Day())
+
+	// * A departure exactly at from was already created
+	first := departures[0]
+	departures, err = Occurrences(template, first, first.Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, departures)
+}
+
+func TestOccurrencesDaylightSaving(t *testing.T) {
+	template := weekdayCommute()
+	template.Timezone = "Europe/London"
+	template.StartDate = "2023-03-20"
+	template.EndDate = ""
+
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	// * Clocks go forward on 26 March, the local departure time stays the same
+	departures, err := Occurrences(template, time.Date(2023, 3, 24, 0, 0, 0, 0, london), time.Date(2023, 3, 28, 0, 0, 0, 0, london))
+	require.NoError(t, err)
+	require.Len(t, departures, 2)
+	require.Equal(t, 8, departures[0].In(london).Hour())
+	require.Equal(t, 8, departures[1].In(london).Hour())
+	require.Equal(t, 8, departures[0].UTC().Hour())
+	require.Equal(t, 7, departures[1].UTC().Hour())
+}
+
+func TestEnded(t *testing.T) {
+	template := weekdayCommute()
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// * The whole end date is still part of the ride
+	ended, err := Ended(template, time.Date(2023, 1, 13, 23, 59, 0, 0, kolkata))
+	require.NoError(t, err)
+	require.False(t, ended)
+
+	ended, err = Ended(template, time.Date(2023, 1, 14, 0, 0, 0, 0, kolkata))
+	require.NoError(t, err)
+	require.True(t, ended)
+
+	template.EndDate = ""
+	ended, err = Ended(template, time.Date(2030, 1, 1, 0, 0, 0, 0, kolkata))
+	require.NoError(t, err)
+	require.False(t, ended)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(weekdayCommute()))
+
+	template := weekdayCommute()
+	template.Timezone = "Mars/Olympus"
+	require.Error(t, Validate(template))
+
+	template = weekdayCommute()
+	template.DepartureTime = "25:00"
+	require.Error(t, Validate(template))
+
+	template = weekdayCommute()
+	template.EndDate = "2022-12-31"
+	require.Error(t, Validate(template))
+}
+
+func TestInstance(t *testing.T) {
+	template := weekdayCommute()
+	template.Subscribers = []models.RecurringSubscriber{
+		{Email: "a@example.com", Status: models.RequestAccepted},
+		{Email: "b@example.com", Status: models.RequestPending},
+		{Email: "c@example.com", Status: models.RequestAccepted},
+		{Email: "d@example.com", Status: models.RequestAccepted},
+	}
+
+	departure := time.Date(2023, 1, 2, 3, 0, 0, 0, time.UTC)
+	ride := Instance(template, departure, nil)
+
+	require.Equal(t, template.ID.Hex(), ride.RecurringID)
+	require.Equal(t, departure.Unix(), ride.Timestamp)
+	require.Equal(t, models.RideScheduled, ride.Status)
+
+	// * Driver first, then accepted subscribers while seats last
+	require.Len(t, ride.Passengers, 3)
+	require.Equal(t, template.Email, ride.Passengers[0].Email)
+	require.Equal(t, "a@example.com", ride.Passengers[1].Email)
+	require.Equal(t, "c@example.com", ride.Passengers[2].Email)
+
+	// * Subscribers already on another ride at that time are skipped
+	ride = Instance(template, departure, map[string]bool{"a@example.com": true})
+	require.Len(t, ride.Passengers, 3)
+	require.Equal(t, "c@example.com", ride.Passengers[1].Email)
+	require.Equal(t, "d@example.com", ride.Passengers[2].Email)
+}
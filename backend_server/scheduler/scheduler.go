@@ -0,0 +1,286 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultHorizon  = 7 * 24 * time.Hour
+	DefaultInterval = time.Hour
+)
+
+// EndedReason is the cancel reason of the rides of a deleted recurring ride.
+const EndedReason = "recurring ride ended"
+
+// Scheduler creates the rides of every active recurring ride ahead of time.
+type Scheduler struct {
+	recurring *mongo.Collection
+	rides     *mongo.Collection
+	horizon   time.Duration // how far ahead rides are created
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func New(recurring *mongo.Collection, rides *mongo.Collection, horizon time.Duration, interval time.Duration) *Scheduler {
+	if horizon <= 0 {
+		horizon = DefaultHorizon
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Scheduler{
+		recurring: recurring,
+		rides:     rides,
+		horizon:   horizon,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run materializes rides every interval until the context is cancelled.
+func (scheduler *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := scheduler.MaterializeAll(ctx); err != nil {
+			log.Printf("cannot materialize recurring rides : %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (scheduler *Scheduler) MaterializeAll(ctx context.Context) error {
+	horizon := scheduler.now().Add(scheduler.horizon).Unix()
+	filter := bson.M{"active": true, "materialized_until": bson.M{"$lt": horizon}}
+
+	cursor, err := scheduler.recurring.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var templates []models.RecurringRide
+	if err := cursor.All(ctx, &templates); err != nil {
+		return err
+	}
+
+	for _, template := range templates {
+		if err := scheduler.Materialize(ctx, template); err != nil {
+			log.Printf("cannot materialize recurring ride %s : %v", template.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// Materialize creates the rides of a recurring ride up to the horizon. Rides
+// are upserted on their departure so running it twice creates nothing new.
+// Rides past their end date are deactivated so they are not picked up again.
+// Rides created while the recurring ride is being deleted are cancelled.
+func (scheduler *Scheduler) Materialize(ctx context.Context, template models.RecurringRide) error {
+	now := scheduler.now()
+	to := now.Add(scheduler.horizon)
+
+	from := now
+	if until := time.Unix(template.MaterializedUntil, 0); until.After(from) {
+		from = until
+	}
+
+	departures, err := Occurrences(template, from, to)
+	if err != nil {
+		return err
+	}
+
+	// * The recurring ride may have been deleted since it was read
+	if err := scheduler.recurring.FindOne(ctx, bson.M{"_id": template.ID, "active": true}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	for _, departure := range departures {
+		busy, err := scheduler.busyPassengers(ctx, template, departure)
+		if err != nil {
+			return err
+		}
+
+		// * The driver is already on another ride at that time, skip this one
+		if busy[template.Email] {
+			continue
+		}
+
+		ride := Instance(template, departure, busy)
+
+		filter := bson.M{"recurring_id": ride.RecurringID, "timestamp": ride.Timestamp}
+		update := bson.M{"$setOnInsert": ride}
+		if _, err := scheduler.rides.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	ended, err := Ended(template, now)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"materialized_until": to.Unix()}
+	if ended {
+		set["active"] = false
+	}
+
+	result, err := scheduler.recurring.UpdateOne(ctx, bson.M{"_id": template.ID, "active": true}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	// * Deleted in the meantime, its rides created above were not cancelled with the others
+	if result.MatchedCount == 0 {
+		filter := bson.M{"recurring_id": template.ID.Hex(), "status": models.RideScheduled}
+		update := bson.M{"$set": bson.M{
+			"status":        models.RideCancelled,
+			"cancelled_at":  scheduler.now().Unix(),
+			"cancel_reason": EndedReason,
+		}}
+		_, err = scheduler.rides.UpdateMany(ctx, filter, update)
+	}
+
+	return err
+}
+
+// Overlaps tells whether the driver is already on an active ride departing
+// around one of the rides the recurring ride would create up to the horizon.
+func (scheduler *Scheduler) Overlaps(ctx context.Context, template models.RecurringRide) (bool, error) {
+	now := scheduler.now()
+
+	departures, err := Occurrences(template, now, now.Add(scheduler.horizon))
+	if err != nil {
+		return false, err
+	}
+	if len(departures) == 0 {
+		return false, nil
+	}
+
+	window := int64(models.RideOverlapWindow / time.Second)
+
+	var windows bson.A
+	for _, departure := range departures {
+		windows = append(windows, bson.M{"timestamp": bson.M{"$gte": departure.Unix() - window, "$lte": departure.Unix() + window}})
+	}
+
+	filter := bson.M{
+		"status":           bson.M{"$in": models.ActiveRideStatuses},
+		"passengers.email": template.Email,
+		"$or":              windows,
+	}
+
+	err = scheduler.rides.FindOne(ctx, filter).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// busyPassengers returns the driver and the accepted subscribers that are
+// already on another active ride departing around departure, so they are not
+// booked twice.
+func (scheduler *Scheduler) busyPassengers(ctx context.Context, template models.RecurringRide, departure time.Time) (map[string]bool, error) {
+	emails := []string{template.Email}
+	for _, subscriber := range template.Subscribers {
+		if subscriber.Status == models.RequestAccepted {
+			emails = append(emails, subscriber.Email)
+		}
+	}
+
+	window := int64(models.RideOverlapWindow / time.Second)
+	filter := bson.M{
+		"status":           bson.M{"$in": models.ActiveRideStatuses},
+		"recurring_id":     bson.M{"$ne": template.ID.Hex()},
+		"passengers.email": bson.M{"$in": emails},
+		"timestamp":        bson.M{"$gte": departure.Unix() - window, "$lte": departure.Unix() + window},
+	}
+	opts := options.Find().SetProjection(bson.M{"passengers.email": 1})
+
+	cursor, err := scheduler.rides.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var rides []models.CreateRideResp
+	if err := cursor.All(ctx, &rides); err != nil {
+		return nil, err
+	}
+
+	busy := make(map[string]bool)
+	for _, ride := range rides {
+		for _, passenger := range ride.Passengers {
+			busy[passenger.Email] = true
+		}
+	}
+
+	return busy, nil
+}
+
+// Instance builds the ride of a recurring ride departing at departure, with the
+// driver and the accepted subscribers that fit in the seats as passengers.
+// Subscribers in busy are left out.
+func Instance(template models.RecurringRide, departure time.Time, busy map[string]bool) models.CreateRideResp {
+	ride := models.CreateRideResp{
+		Origin:      template.Origin,
+		Destination: template.Destination,
+		Seats:       template.Seats,
+		Email:       template.Email,
+		Phone:       template.Phone,
+		Price:       template.Price,
+		PlaceId:     template.PlaceId,
+		Timestamp:   departure.Unix(),
+		Status:      models.RideScheduled,
+		GeoJSON:     template.GeoJSON,
+		RecurringID: template.ID.Hex(),
+		Passengers: []models.Passenger{
+			{
+				RequestID: "0",
+				Email:     template.Email,
+				Origin:    template.Origin,
+				Phone:     template.Phone,
+				Name:      template.Name,
+				OriginLat: template.OriginLat,
+				OriginLng: template.OriginLng,
+			},
+		},
+	}
+
+	for _, subscriber := range template.Subscribers {
+		if subscriber.Status != models.RequestAccepted || busy[subscriber.Email] || len(ride.Passengers) >= ride.Seats {
+			continue
+		}
+
+		ride.Passengers = append(ride.Passengers, models.Passenger{
+			Email:     subscriber.Email,
+			Phone:     subscriber.Phone,
+			Name:      subscriber.Name,
+			Origin:    subscriber.Origin,
+			OriginLat: subscriber.OriginLat,
+			OriginLng: subscriber.OriginLng,
+		})
+	}
+
+	return ride
+}
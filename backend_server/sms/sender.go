@@ -0,0 +1,33 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Sender delivers text messages to a phone number.
+type Sender interface {
+	Send(ctx context.Context, phone string, message string) error
+}
+
+// LogSender only writes messages to the server log, for local development.
+type LogSender struct{}
+
+func NewLogSender() Sender {
+	return LogSender{}
+}
+
+func (LogSender) Send(ctx context.Context, phone string, message string) error {
+	log.Printf("sms to %s : %s", phone, message)
+	return nil
+}
+
+func NewSender(provider string) (Sender, error) {
+	switch provider {
+	case "", "log":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %s", provider)
+	}
+}
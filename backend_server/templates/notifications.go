@@ -0,0 +1,158 @@
+package templates
+
+import "github.com/achintya-7/car_pooling_backend/models"
+
+type notificationText struct {
+	title string
+	body  string
+}
+
+var notificationTexts = map[string]map[models.NotificationKind]notificationText{
+	"en": {
+		models.NotificationRideRequested: {
+			title: "New ride request",
+			body:  "New request from {{.SenderName}} has been made for the Ride {{.RideID}}",
+		},
+		models.NotificationRequestAccepted: {
+			title: "Ride request accepted",
+			body:  "Your request for ride has been ACCEPTED by the driver {{.DriverName}}",
+		},
+		models.NotificationRequestRejected: {
+			title: "Ride request declined",
+			body:  "Your request for ride has been DECLINED by the driver {{.DriverName}}",
+		},
+		models.NotificationRideCancelled: {
+			title: "Ride cancelled",
+			body:  "Ride has been cancelled by driver {{.DriverName}}",
+		},
+		models.NotificationRideCompleted: {
+			title: "Ride completed",
+			body:  "Your ride with {{.DriverName}} has been completed, the ride summary is now available",
+		},
+		models.NotificationRidePriceIncreased: {
+			title: "Ride price increased",
+			body:  "{{.DriverName}} raised the price of your ride to {{.Price}}, please confirm or withdraw from the ride",
+		},
+		models.NotificationRideRescheduled: {
+			title: "Ride rescheduled",
+			body:  "{{.DriverName}} changed the departure time of your ride, you can withdraw if it no longer suits you",
+		},
+		models.NotificationRideRouteChanged: {
+			title: "Ride route changed",
+			body:  "{{.DriverName}} changed the route of your ride",
+		},
+		models.NotificationPassengerWithdrew: {
+			title: "Passenger withdrew",
+			body:  "{{.SenderName}} has withdrawn from the Ride {{.RideID}}",
+		},
+		models.NotificationPassengerRemoved: {
+			title: "Removed from ride",
+			body:  "You have been removed from the ride by the driver {{.DriverName}}",
+		},
+		models.NotificationSubscriptionRequested: {
+			title: "New subscription request",
+			body:  "{{.SenderName}} wants to join your recurring ride {{.RecurringID}}",
+		},
+		models.NotificationSubscriptionAccepted: {
+			title: "Subscription accepted",
+			body:  "{{.DriverName}} accepted you on their recurring ride, upcoming rides include you now",
+		},
+		models.NotificationRecurringRideEnded: {
+			title: "Recurring ride ended",
+			body:  "{{.DriverName}} stopped their recurring ride, upcoming rides have been cancelled",
+		},
+		models.NotificationSubscriptionCancelled: {
+			title: "Subscription cancelled",
+			body:  "{{.SenderName}} left your recurring ride {{.RecurringID}}",
+		},
+		models.NotificationSubscriptionRejected: {
+			title: "Subscription rejected",
+			body:  "{{.DriverName}} could not take you on their recurring ride",
+		},
+		models.NotificationSubscriptionRemoved: {
+			title: "Removed from recurring ride",
+			body:  "{{.DriverName}} removed you from their recurring ride, upcoming rides no longer include you",
+		},
+	},
+	"hi": {
+		models.NotificationRideRequested: {
+			title: "नया सवारी अनुरोध",
+			body:  "{{.SenderName}} ने सवारी {{.RideID}} के लिए नया अनुरोध भेजा है",
+		},
+		models.NotificationRequestAccepted: {
+			title: "सवारी अनुरोध स्वीकार",
+			body:  "ड्राइवर {{.DriverName}} ने आपका सवारी अनुरोध स्वीकार कर लिया है",
+		},
+		models.NotificationRequestRejected: {
+			title: "सवारी अनुरोध अस्वीकार",
+			body:  "ड्राइवर {{.DriverName}} ने आपका सवारी अनुरोध अस्वीकार कर दिया है",
+		},
+		models.NotificationRideCancelled: {
+			title: "सवारी रद्द",
+			body:  "ड्राइवर {{.DriverName}} ने सवारी रद्द कर दी है",
+		},
+		models.NotificationRideCompleted: {
+			title: "सवारी पूरी हुई",
+			body:  "{{.DriverName}} के साथ आपकी सवारी पूरी हो गई है, सवारी का सारांश अब उपलब्ध है",
+		},
+		models.NotificationRidePriceIncreased: {
+			title: "सवारी का किराया बढ़ा",
+			body:  "{{.DriverName}} ने आपकी सवारी का किराया {{.Price}} कर दिया है, कृपया पुष्टि करें या सवारी से हट जाएं",
+		},
+		models.NotificationRideRescheduled: {
+			title: "सवारी का समय बदला",
+			body:  "{{.DriverName}} ने आपकी सवारी का प्रस्थान समय बदल दिया है, यदि यह आपके लिए उपयुक्त नहीं है तो आप सवारी से हट सकते हैं",
+		},
+		models.NotificationRideRouteChanged: {
+			title: "सवारी का मार्ग बदला",
+			body:  "{{.DriverName}} ने आपकी सवारी का मार्ग बदल दिया है",
+		},
+		models.NotificationPassengerWithdrew: {
+			title: "यात्री ने सवारी छोड़ी",
+			body:  "{{.SenderName}} सवारी {{.RideID}} से हट गए हैं",
+		},
+		models.NotificationPassengerRemoved: {
+			title: "सवारी से हटाया गया",
+			body:  "ड्राइवर {{.DriverName}} ने आपको सवारी से हटा दिया है",
+		},
+		models.NotificationSubscriptionRequested: {
+			title: "नया सदस्यता अनुरोध",
+			body:  "{{.SenderName}} आपकी नियमित सवारी {{.RecurringID}} में शामिल होना चाहते हैं",
+		},
+		models.NotificationSubscriptionAccepted: {
+			title: "सदस्यता स्वीकार",
+			body:  "{{.DriverName}} ने आपको अपनी नियमित सवारी में स्वीकार कर लिया है, आने वाली सवारियों में अब आप शामिल हैं",
+		},
+		models.NotificationRecurringRideEnded: {
+			title: "नियमित सवारी बंद",
+			body:  "{{.DriverName}} ने अपनी नियमित सवारी बंद कर दी है, आने वाली सवारियां रद्द कर दी गई हैं",
+		},
+		models.NotificationSubscriptionCancelled: {
+			title: "सदस्यता रद्द",
+			body:  "{{.SenderName}} ने आपकी नियमित सवारी {{.RecurringID}} छोड़ दी है",
+		},
+		models.NotificationSubscriptionRejected: {
+			title: "सदस्यता अस्वीकार",
+			body:  "{{.DriverName}} आपको अपनी नियमित सवारी में नहीं ले सकते",
+		},
+		models.NotificationSubscriptionRemoved: {
+			title: "नियमित सवारी से हटाया गया",
+			body:  "{{.DriverName}} ने आपको अपनी नियमित सवारी से हटा दिया है, आने वाली सवारियों में अब आप शामिल नहीं हैं",
+		},
+	},
+}
+
+// DefaultRegistry returns a registry with the built in English and Hindi texts.
+func DefaultRegistry() (*Registry, error) {
+	registry := NewRegistry()
+
+	for language, texts := range notificationTexts {
+		for kind, text := range texts {
+			if err := registry.Register(language, kind, text.title, text.body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,99 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+)
+
+const DefaultLanguage = "en"
+
+type notificationTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// Registry renders notification text per language and kind, falling back to
+// the default language when a translation is missing.
+type Registry struct {
+	notifications map[string]map[models.NotificationKind]notificationTemplate
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		notifications: make(map[string]map[models.NotificationKind]notificationTemplate),
+	}
+}
+
+func (registry *Registry) Register(language string, kind models.NotificationKind, title string, body string) error {
+	name := fmt.Sprintf("%s.%s", language, kind)
+
+	titleTmpl, err := template.New(name + ".title").Option("missingkey=error").Parse(title)
+	if err != nil {
+		return fmt.Errorf("cannot parse title template %s : %v", name, err)
+	}
+
+	bodyTmpl, err := template.New(name + ".body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return fmt.Errorf("cannot parse body template %s : %v", name, err)
+	}
+
+	if registry.notifications[language] == nil {
+		registry.notifications[language] = make(map[models.NotificationKind]notificationTemplate)
+	}
+	registry.notifications[language][kind] = notificationTemplate{title: titleTmpl, body: bodyTmpl}
+
+	return nil
+}
+
+// Supports reports whether the language has any template registered.
+func (registry *Registry) Supports(language string) bool {
+	_, ok := registry.notifications[language]
+	return ok
+}
+
+func (registry *Registry) lookup(language string, kind models.NotificationKind) (notificationTemplate, bool) {
+	if tmpl, ok := registry.notifications[language][kind]; ok {
+		return tmpl, true
+	}
+
+	tmpl, ok := registry.notifications[DefaultLanguage][kind]
+	return tmpl, ok
+}
+
+// Render returns the title and body of a notification.
+func (registry *Registry) Render(language string, notification models.NotificationModel) (string, string, error) {
+	tmpl, ok := registry.lookup(language, notification.Type)
+	if !ok {
+		return "", "", fmt.Errorf("no template for notification kind %s", notification.Type)
+	}
+
+	var title, body bytes.Buffer
+	if err := tmpl.title.Execute(&title, notification); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.body.Execute(&body, notification); err != nil {
+		return "", "", err
+	}
+
+	return title.String(), body.String(), nil
+}
+
+// RenderNotification fills Title and Content in place. Legacy notifications
+// that were stored with their content are left untouched.
+func (registry *Registry) RenderNotification(language string, notification *models.NotificationModel) error {
+	if notification.Content != "" {
+		return nil
+	}
+
+	title, body, err := registry.Render(language, *notification)
+	if err != nil {
+		return err
+	}
+
+	notification.Title = title
+	notification.Content = body
+	return nil
+}
@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/achintya-7/car_pooling_backend/models"
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	// * Every kind needs a text in every language
+	for language := range notificationTexts {
+		for kind := range notificationTexts[DefaultLanguage] {
+			_, ok := registry.notifications[language][kind]
+			require.True(t, ok, "missing %s template for %s", language, kind)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	driverName := utils.RandomString(8)
+	notification := models.NotificationModel{
+		Type:       models.NotificationRequestAccepted,
+		DriverName: driverName,
+	}
+
+	title, body, err := registry.Render("en", notification)
+	require.NoError(t, err)
+	require.Equal(t, "Ride request accepted", title)
+	require.Equal(t, "Your request for ride has been ACCEPTED by the driver "+driverName, body)
+
+	_, body, err = registry.Render("hi", notification)
+	require.NoError(t, err)
+	require.Contains(t, body, driverName)
+	require.Contains(t, body, "स्वीकार")
+
+	// * Unknown languages fall back to English
+	_, fallback, err := registry.Render("fr", notification)
+	require.NoError(t, err)
+	require.Equal(t, "Your request for ride has been ACCEPTED by the driver "+driverName, fallback)
+
+	_, _, err = registry.Render("en", models.NotificationModel{Type: models.NotificationKind(99)})
+	require.Error(t, err)
+}
+
+func TestRenderNotificationKeepsLegacyContent(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	notification := models.NotificationModel{Type: models.NotificationRideCancelled, Content: "legacy"}
+	require.NoError(t, registry.RenderNotification("hi", &notification))
+	require.Equal(t, "legacy", notification.Content)
+	require.Empty(t, notification.Title)
+}
+
+func TestNotificationKindJSON(t *testing.T) {
+	data, err := json.Marshal(models.NotificationRideCancelled)
+	require.NoError(t, err)
+	require.Equal(t, `"ride_cancelled"`, string(data))
+
+	var kind models.NotificationKind
+	require.NoError(t, json.Unmarshal([]byte(`"request_rejected"`), &kind))
+	require.Equal(t, models.NotificationRequestRejected, kind)
+
+	require.NoError(t, json.Unmarshal([]byte(`1`), &kind))
+	require.Equal(t, models.NotificationRequestAccepted, kind)
+
+	require.Error(t, json.Unmarshal([]byte(`"unknown"`), &kind))
+}
@@ -0,0 +1,170 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const minSecretKeySize = 32
+
+// JWTKey is a key known to a JWTMaker, identified in tokens by the kid header.
+type JWTKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   interface{} // nil for keys that are only used for verification
+	VerifyKey interface{}
+}
+
+// JWTMaker signs tokens with a single key and verifies them with any of its
+// known keys, so a secret can be rotated without invalidating issued tokens.
+type JWTMaker struct {
+	signingKey JWTKey
+	keys       map[string]JWTKey
+}
+
+func NewJWTMaker(signingKey JWTKey, verificationKeys ...JWTKey) (Maker, error) {
+	if signingKey.ID == "" {
+		return nil, errors.New("signing key id is empty")
+	}
+	if signingKey.SignKey == nil {
+		return nil, fmt.Errorf("key %s cannot be used for signing", signingKey.ID)
+	}
+
+	maker := &JWTMaker{
+		signingKey: signingKey,
+		keys:       map[string]JWTKey{signingKey.ID: signingKey},
+	}
+
+	for _, key := range verificationKeys {
+		if _, ok := maker.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		maker.keys[key.ID] = key
+	}
+
+	return maker, nil
+}
+
+// NewHMACKey returns a HS256 key, the secret is used both to sign and verify.
+func NewHMACKey(id string, secretKey string) (JWTKey, error) {
+	if len(secretKey) < minSecretKeySize {
+		return JWTKey{}, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+
+	key := JWTKey{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   []byte(secretKey),
+		VerifyKey: []byte(secretKey),
+	}
+
+	return key, nil
+}
+
+// NewRSAKeyFromPEM returns a RS256 key from a PEM encoded private key, or a
+// verification only key from a PEM encoded public key.
+func NewRSAKeyFromPEM(id string, pemBytes []byte) (JWTKey, error) {
+	key := JWTKey{ID: id, Method: jwt.SigningMethodRS256}
+
+	if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		key.SignKey = privateKey
+		key.VerifyKey = &privateKey.PublicKey
+		return key, nil
+	}
+
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("cannot parse RSA key %s : %v", id, err)
+	}
+
+	key.VerifyKey = publicKey
+	return key, nil
+}
+
+// NewEdDSAKeyFromPEM returns an EdDSA key from a PEM encoded Ed25519 private
+// key, or a verification only key from a PEM encoded public key.
+func NewEdDSAKeyFromPEM(id string, pemBytes []byte) (JWTKey, error) {
+	key := JWTKey{ID: id, Method: jwt.SigningMethodEdDSA}
+
+	if privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		key.SignKey = privateKey
+		key.VerifyKey = privateKey.(ed25519.PrivateKey).Public()
+		return key, nil
+	}
+
+	publicKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("cannot parse EdDSA key %s : %v", id, err)
+	}
+
+	key.VerifyKey = publicKey
+	return key, nil
+}
+
+// ParseJWTKey builds a key for the given algorithm. The material is the
+// secret for HS256 and a PEM block for RS256 and EdDSA.
+func ParseJWTKey(algorithm string, id string, material []byte) (JWTKey, error) {
+	switch algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		return NewHMACKey(id, string(material))
+	case jwt.SigningMethodRS256.Alg():
+		return NewRSAKeyFromPEM(id, material)
+	case jwt.SigningMethodEdDSA.Alg():
+		return NewEdDSAKeyFromPEM(id, material)
+	default:
+		return JWTKey{}, fmt.Errorf("unsupported jwt algorithm %s", algorithm)
+	}
+}
+
+func (maker *JWTMaker) CreateToken(email string, phone string, name string, role Role, duration time.Duration) (string, error) {
+	payload, err := NewPayload(email, phone, name, role, duration)
+	if err != nil {
+		return "", err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.signingKey.Method, payload)
+	jwtToken.Header["kid"] = maker.signingKey.ID
+
+	return jwtToken.SignedString(maker.signingKey.SignKey)
+}
+
+func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(jwtToken *jwt.Token) (interface{}, error) {
+		kid, ok := jwtToken.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		key, ok := maker.keys[kid]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		// * Never let the token pick the algorithm, e.g. HS256 signed with a RSA public key
+		if jwtToken.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		return key.VerifyKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
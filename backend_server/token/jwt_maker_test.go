@@ -0,0 +1,181 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func randomHMACKey(t *testing.T, id string) JWTKey {
+	key, err := NewHMACKey(id, utils.RandomString(32))
+	require.NoError(t, err)
+	return key
+}
+
+func randomRSAKey(t *testing.T, id string) JWTKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	key, err := NewRSAKeyFromPEM(id, pemBytes)
+	require.NoError(t, err)
+	return key
+}
+
+func randomEdDSAKey(t *testing.T, id string) JWTKey {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	key, err := NewEdDSAKeyFromPEM(id, pemBytes)
+	require.NoError(t, err)
+	return key
+}
+
+func TestJWTMaker(t *testing.T) {
+	keys := map[string]JWTKey{
+		"HS256": randomHMACKey(t, "hs-1"),
+		"RS256": randomRSAKey(t, "rs-1"),
+		"EdDSA": randomEdDSAKey(t, "ed-1"),
+	}
+
+	for alg, key := range keys {
+		t.Run(alg, func(t *testing.T) {
+			maker, err := NewJWTMaker(key)
+			require.NoError(t, err)
+
+			email := utils.RandomEmail()
+			phone := utils.RandomString(10)
+			name := utils.RandomString(10)
+			duration := time.Hour
+
+			issuedAt := time.Now()
+
+			token, err := maker.CreateToken(email, phone, name, RoleDriver, duration)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			require.NotZero(t, payload.ID)
+			require.Equal(t, email, payload.Email)
+			require.Equal(t, RoleDriver, payload.Role)
+			require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second*2)
+		})
+	}
+}
+
+func TestExpiredJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(randomHMACKey(t, "hs-1"))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RolePassenger, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestTamperedJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(randomRSAKey(t, "rs-1"))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RolePassenger, time.Minute)
+	require.NoError(t, err)
+
+	// * Swap the claims for an admin payload while keeping the original signature
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	forged, err := NewPayload(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RoleAdmin, time.Minute)
+	require.NoError(t, err)
+	forgedToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, forged).SigningString()
+	require.NoError(t, err)
+	parts[1] = strings.Split(forgedToken, ".")[1]
+
+	payload, err := maker.VerifyToken(strings.Join(parts, "."))
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenAlgNone(t *testing.T) {
+	maker, err := NewJWTMaker(randomHMACKey(t, "hs-1"))
+	require.NoError(t, err)
+
+	payload, err := NewPayload(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RoleAdmin, time.Minute)
+	require.NoError(t, err)
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	jwtToken.Header["kid"] = "hs-1"
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldKey := randomHMACKey(t, "hs-1")
+	newKey := randomHMACKey(t, "hs-2")
+
+	oldMaker, err := NewJWTMaker(oldKey)
+	require.NoError(t, err)
+
+	oldToken, err := oldMaker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RolePassenger, time.Minute)
+	require.NoError(t, err)
+
+	// * After rotation tokens signed with the old key stay valid
+	maker, err := NewJWTMaker(newKey, oldKey)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	newToken, err := maker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RolePassenger, time.Minute)
+	require.NoError(t, err)
+
+	// * Once the old key is dropped its tokens are rejected
+	retiredMaker, err := NewJWTMaker(newKey)
+	require.NoError(t, err)
+
+	payload, err = retiredMaker.VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	payload, err = retiredMaker.VerifyToken(newToken)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+}
+
+func TestInvalidJWTKey(t *testing.T) {
+	_, err := NewHMACKey("hs-1", utils.RandomString(16))
+	require.Error(t, err)
+
+	_, err = NewRSAKeyFromPEM("rs-1", []byte(utils.RandomString(32)))
+	require.Error(t, err)
+
+	_, err = ParseJWTKey("HS512", "hs-1", []byte(utils.RandomString(32)))
+	require.Error(t, err)
+
+	_, err = NewJWTMaker(randomHMACKey(t, "hs-1"), randomHMACKey(t, "hs-1"))
+	require.Error(t, err)
+}
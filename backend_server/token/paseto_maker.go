@@ -26,9 +26,9 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(email string, phone string, name string, duration time.Duration) (string, error) {
+func (maker *PasetoMaker) CreateToken(email string, phone string, name string, role Role, duration time.Duration) (string, error) {
 
-	payLoad, err := NewPayload(email, phone, name, duration)
+	payLoad, err := NewPayload(email, phone, name, role, duration)
 	if err != nil {
 		return "", err
 	}
@@ -19,7 +19,7 @@ func TestPasetoMaker(t *testing.T) {
 
 	issuedAt := time.Now()
 
-	token, err := maker.CreateToken(email, phone, name, duration)
+	token, err := maker.CreateToken(email, phone, name, RoleDriver, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -28,5 +28,7 @@ func TestPasetoMaker(t *testing.T) {
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
+	require.Equal(t, email, payload.Email)
+	require.Equal(t, RoleDriver, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second*2)
 }
@@ -0,0 +1,107 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+var ErrVerifyOnly = errors.New("token maker can only verify tokens")
+
+// PublicKeyMaker is a Maker whose tokens can be verified by anyone holding
+// its public key, without being able to forge new ones.
+type PublicKeyMaker interface {
+	Maker
+	PublicKey() ed25519.PublicKey
+}
+
+// PasetoPublicMaker signs v2.public tokens with an Ed25519 key pair.
+type PasetoPublicMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey // nil for verify only makers
+	publicKey  ed25519.PublicKey
+}
+
+// NewPasetoPublicMaker creates a maker from a hex encoded Ed25519 seed or
+// private key.
+func NewPasetoPublicMaker(privateKeyHex string) (PublicKeyMaker, error) {
+	key, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode private key : %v", err)
+	}
+
+	var privateKey ed25519.PrivateKey
+	switch len(key) {
+	case ed25519.SeedSize:
+		privateKey = ed25519.NewKeyFromSeed(key)
+	case ed25519.PrivateKeySize:
+		privateKey = ed25519.PrivateKey(key)
+	default:
+		return nil, fmt.Errorf("invalid private key size : must be %d or %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+
+	return maker, nil
+}
+
+// NewPasetoPublicVerifier creates a maker from a hex encoded Ed25519 public
+// key. It verifies tokens but refuses to create them.
+func NewPasetoPublicVerifier(publicKeyHex string) (PublicKeyMaker, error) {
+	key, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode public key : %v", err)
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size : must be %d bytes", ed25519.PublicKeySize)
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:    paseto.NewV2(),
+		publicKey: ed25519.PublicKey(key),
+	}
+
+	return maker, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(email string, phone string, name string, role Role, duration time.Duration) (string, error) {
+	if maker.privateKey == nil {
+		return "", ErrVerifyOnly
+	}
+
+	payload, err := NewPayload(email, phone, name, role, duration)
+	if err != nil {
+		return "", err
+	}
+
+	return maker.paseto.Sign(maker.privateKey, payload, nil)
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	payload := &Payload{}
+
+	err := maker.paseto.Verify(token, maker.publicKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublicMaker) PublicKey() ed25519.PublicKey {
+	return maker.publicKey
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/achintya-7/car_pooling_backend/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func randomEd25519Seed(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	return hex.EncodeToString(privateKey.Seed())
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Seed(t))
+	require.NoError(t, err)
+
+	email := utils.RandomEmail()
+	phone := utils.RandomString(10)
+	name := utils.RandomString(10)
+	duration := time.Hour
+
+	issuedAt := time.Now()
+
+	token, err := maker.CreateToken(email, phone, name, RolePassenger, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, email, payload.Email)
+	require.Equal(t, RolePassenger, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second*2)
+
+	// * A verifier built from the public key accepts the token but cannot mint new ones
+	verifier, err := NewPasetoPublicVerifier(hex.EncodeToString(maker.PublicKey()))
+	require.NoError(t, err)
+
+	payload, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, email, payload.Email)
+
+	token, err = verifier.CreateToken(email, phone, name, RoleAdmin, duration)
+	require.ErrorIs(t, err, ErrVerifyOnly)
+	require.Empty(t, token)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Seed(t))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RolePassenger, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicTokenWrongKey(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Seed(t))
+	require.NoError(t, err)
+
+	otherMaker, err := NewPasetoPublicMaker(randomEd25519Seed(t))
+	require.NoError(t, err)
+
+	token, err := otherMaker.CreateToken(utils.RandomEmail(), utils.RandomString(10), utils.RandomString(10), RoleAdmin, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidPasetoPublicKey(t *testing.T) {
+	_, err := NewPasetoPublicMaker(utils.RandomString(32))
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicMaker(hex.EncodeToString([]byte(utils.RandomString(16))))
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicVerifier(hex.EncodeToString([]byte(utils.RandomString(16))))
+	require.Error(t, err)
+}
@@ -12,16 +12,25 @@ var (
 	ErrExpiredToken = errors.New("expired token")
 )
 
+type Role string
+
+const (
+	RolePassenger Role = "passenger"
+	RoleDriver    Role = "driver"
+	RoleAdmin     Role = "admin"
+)
+
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Phone     string    `json:"phone"`
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-func NewPayload(email, phone, name string, duration time.Duration) (*Payload, error) {
+func NewPayload(email, phone, name string, role Role, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -32,6 +41,7 @@ func NewPayload(email, phone, name string, duration time.Duration) (*Payload, er
 		Email:     email,
 		Phone:     phone,
 		Name:      name,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
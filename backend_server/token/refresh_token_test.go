@@ -0,0 +1,21 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshToken(t *testing.T) {
+	refreshToken1, err := NewRefreshToken()
+	require.NoError(t, err)
+	require.NotEmpty(t, refreshToken1)
+
+	refreshToken2, err := NewRefreshToken()
+	require.NoError(t, err)
+	require.NotEqual(t, refreshToken1, refreshToken2)
+
+	require.Equal(t, HashRefreshToken(refreshToken1), HashRefreshToken(refreshToken1))
+	require.NotEqual(t, HashRefreshToken(refreshToken1), HashRefreshToken(refreshToken2))
+	require.NotContains(t, HashRefreshToken(refreshToken1), refreshToken1)
+}
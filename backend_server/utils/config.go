@@ -7,12 +7,52 @@ import (
 )
 
 type Config struct {
-	MongoUri            string        `mapstructure:"MONGO_URI"`
-	DBName              string        `mapstructure:"DB_NAME"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSecret         string        `mapstructure:"TOKEN_SECRET"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	MapsKey             string        `mapstructure:"MAPS_KEY"`
+	MongoUri             string        `mapstructure:"MONGO_URI"`
+	DBName               string        `mapstructure:"DB_NAME"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"` // paseto_local (default), paseto_public or jwt
+	TokenSecret          string        `mapstructure:"TOKEN_SECRET"`
+	TokenPrivateKey      string        `mapstructure:"TOKEN_PRIVATE_KEY"` // hex encoded Ed25519 seed for paseto_public
+	JWTAlgorithm         string        `mapstructure:"JWT_ALGORITHM"`     // HS256 (default), RS256 or EdDSA
+	JWTKeyID             string        `mapstructure:"JWT_KEY_ID"`
+	JWTPrivateKeyFile    string        `mapstructure:"JWT_PRIVATE_KEY_FILE"`  // PEM file for RS256 and EdDSA
+	JWTVerificationKeys  []string      `mapstructure:"JWT_VERIFICATION_KEYS"` // kid:secret for HS256, kid:pem_file otherwise
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	FirebaseProjectID    string        `mapstructure:"FIREBASE_PROJECT_ID"`
+	SMSProvider          string        `mapstructure:"SMS_PROVIDER"`  // log (default)
+	PushProvider         string        `mapstructure:"PUSH_PROVIDER"` // log (default) or fcm
+	FCMCredentialsFile   string        `mapstructure:"FCM_CREDENTIALS_FILE"`
+	MapsProvider         string        `mapstructure:"MAPS_PROVIDER"` // google (default), osm or fake
+	MapsKey              string        `mapstructure:"MAPS_KEY"`
+	// * Self hosted servers, required by the osm provider
+	NominatimURL string `mapstructure:"NOMINATIM_URL"`
+	OSRMURL      string `mapstructure:"OSRM_URL"`
+	// * Maps api calls, defaults in mapsApi when empty
+	MapsTimeout          time.Duration `mapstructure:"MAPS_TIMEOUT"`
+	MapsMaxRetries       int           `mapstructure:"MAPS_MAX_RETRIES"` // -1 disables retries, 2 when empty
+	MapsBreakerThreshold int           `mapstructure:"MAPS_BREAKER_THRESHOLD"`
+	MapsBreakerCooldown  time.Duration `mapstructure:"MAPS_BREAKER_COOLDOWN"`
+	MapsCacheStore       string        `mapstructure:"MAPS_CACHE_STORE"` // memory (default) or mongo to persist across restarts
+	MapsCacheSize        int           `mapstructure:"MAPS_CACHE_SIZE"`
+	MapsPredictionsTTL   time.Duration `mapstructure:"MAPS_PREDICTIONS_TTL"`
+	MapsPlaceTTL         time.Duration `mapstructure:"MAPS_PLACE_TTL"`
+	MapsRouteTTL         time.Duration `mapstructure:"MAPS_ROUTE_TTL"`
+	// * Place predictions favour the places around the campus, within the radius in meters
+	MapsBiasLat          float64   `mapstructure:"MAPS_BIAS_LAT"`
+	MapsBiasLng          float64   `mapstructure:"MAPS_BIAS_LNG"`
+	MapsBiasRadius       float64   `mapstructure:"MAPS_BIAS_RADIUS"`
+	MapsBiasBounds       []float64 `mapstructure:"MAPS_BIAS_BOUNDS"` // south,west,north,east, used without MAPS_BIAS_LAT and MAPS_BIAS_LNG
+	MapsCountries        []string  `mapstructure:"MAPS_COUNTRIES"`   // ISO 3166-1 alpha-2 codes
+	MapsPredictionsLimit int       `mapstructure:"MAPS_PREDICTIONS_LIMIT"`
+	// * Departure changes larger than this notify the passengers, 15m when empty
+	RideRescheduleThreshold time.Duration `mapstructure:"RIDE_RESCHEDULE_THRESHOLD"`
+	// * Passengers cannot leave or be removed this close to departure, 1h when empty
+	RideCancellationCutoff time.Duration `mapstructure:"RIDE_CANCELLATION_CUTOFF"`
+	// * Rides of recurring rides are created this far ahead, 168h when empty
+	RecurringRideHorizon time.Duration `mapstructure:"RECURRING_RIDE_HORIZON"`
+	SchedulerInterval    time.Duration `mapstructure:"SCHEDULER_INTERVAL"` // 1h when empty
+	AdminEmails          []string      `mapstructure:"ADMIN_EMAILS"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const earthRadius = 6371000 // meters
+
+// Haversine returns the great circle distance in meters between two points.
+func Haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// GeoJSONCoordinates returns the [lng, lat] positions of a MultiPoint or
+// LineString, whether it was built in memory or decoded from the database.
+func GeoJSONCoordinates(geo primitive.M) [][]float64 {
+	var coordinates [][]float64
+
+	switch raw := geo["coordinates"].(type) {
+	case [][]float64:
+		return raw
+	case primitive.A:
+		for _, position := range raw {
+			values, ok := position.(primitive.A)
+			if !ok || len(values) < 2 {
+				continue
+			}
+
+			lng, okLng := values[0].(float64)
+			lat, okLat := values[1].(float64)
+			if okLng && okLat {
+				coordinates = append(coordinates, []float64{lng, lat})
+			}
+		}
+	}
+
+	return coordinates
+}
+
+// PathLength returns the length in meters of a path of [lng, lat] positions.
+func PathLength(coordinates [][]float64) float64 {
+	length := 0.0
+	for i := 1; i < len(coordinates); i++ {
+		length += Haversine(coordinates[i-1][1], coordinates[i-1][0], coordinates[i][1], coordinates[i][0])
+	}
+
+	return length
+}
+
+// NearestIndex returns the index of the position closest to the point and
+// its distance in meters, or -1 for an empty path.
+func NearestIndex(coordinates [][]float64, lat, lng float64) (int, float64) {
+	index, nearest := -1, math.Inf(1)
+
+	for i, position := range coordinates {
+		if d := Haversine(lat, lng, position[1], position[0]); d < nearest {
+			index, nearest = i, d
+		}
+	}
+
+	return index, nearest
+}
+
+// ProjectOnPath returns the position of the path closest to the point as
+// [lng, lat], the index of the segment it lies on and its distance in meters,
+// or a nil position for an empty path.
+func ProjectOnPath(coordinates [][]float64, lat, lng float64) ([]float64, int, float64) {
+	if len(coordinates) == 0 {
+		return nil, -1, math.Inf(1)
+	}
+	if len(coordinates) == 1 {
+		return coordinates[0], 0, Haversine(lat, lng, coordinates[0][1], coordinates[0][0])
+	}
+
+	// * Segments are short enough to project on a plane scaled at the point latitude
+	scale := math.Cos(lat * math.Pi / 180)
+
+	var closest []float64
+	segment, nearest := -1, math.Inf(1)
+
+	for i := 1; i < len(coordinates); i++ {
+		a, b := coordinates[i-1], coordinates[i]
+
+		dx, dy := (b[0]-a[0])*scale, b[1]-a[1]
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = ((lng-a[0])*scale*dx + (lat-a[1])*dy) / length
+			t = math.Max(0, math.Min(1, t))
+		}
+
+		position := []float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+		if d := Haversine(lat, lng, position[1], position[0]); d < nearest {
+			closest, segment, nearest = position, i-1, d
+		}
+	}
+
+	return closest, segment, nearest
+}
+
+// Detour returns how many meters longer the path gets when the driver leaves
+// it to go through the point and comes back on the next position.
+func Detour(coordinates [][]float64, lat, lng float64) float64 {
+	if len(coordinates) == 0 {
+		return math.Inf(1)
+	}
+	if len(coordinates) == 1 {
+		return 2 * Haversine(lat, lng, coordinates[0][1], coordinates[0][0])
+	}
+
+	detour := math.Inf(1)
+	for i := 1; i < len(coordinates); i++ {
+		a, b := coordinates[i-1], coordinates[i]
+
+		extra := Haversine(a[1], a[0], lat, lng) + Haversine(lat, lng, b[1], b[0]) - Haversine(a[1], a[0], b[1], b[0])
+		detour = math.Min(detour, extra)
+	}
+
+	return math.Max(0, detour)
+}
+
+// DistanceAlong returns how many meters into the path a position on the given
+// segment is, as returned by ProjectOnPath.
+func DistanceAlong(coordinates [][]float64, segment int, position []float64) float64 {
+	if segment < 0 || segment >= len(coordinates) || position == nil {
+		return 0
+	}
+
+	start := coordinates[segment]
+	return PathLength(coordinates[:segment+1]) + Haversine(start[1], start[0], position[1], position[0])
+}
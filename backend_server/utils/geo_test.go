@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHaversine(t *testing.T) {
+	require.Zero(t, Haversine(28.54, 77.33, 28.54, 77.33))
+
+	// * One degree of latitude is roughly 111 km
+	require.InDelta(t, 111195, Haversine(28, 77, 29, 77), 100)
+}
+
+func TestGeoJSONCoordinates(t *testing.T) {
+	built := primitive.M{"type": "LineString", "coordinates": [][]float64{{77, 28}, {77, 29}}}
+	require.Equal(t, [][]float64{{77, 28}, {77, 29}}, GeoJSONCoordinates(built))
+
+	decoded := primitive.M{"type": "LineString", "coordinates": primitive.A{primitive.A{77.0, 28.0}, primitive.A{77.0, 29.0}}}
+	require.Equal(t, [][]float64{{77, 28}, {77, 29}}, GeoJSONCoordinates(decoded))
+
+	require.Empty(t, GeoJSONCoordinates(primitive.M{}))
+}
+
+func TestPathLength(t *testing.T) {
+	path := [][]float64{{77, 28}, {77, 28.5}, {77, 29}}
+	require.InDelta(t, Haversine(28, 77, 29, 77), PathLength(path), 1)
+
+	index, distance := NearestIndex(path, 28.49, 77)
+	require.Equal(t, 1, index)
+	require.InDelta(t, 1112, distance, 5)
+
+	index, _ = NearestIndex(nil, 28, 77)
+	require.Equal(t, -1, index)
+}
+
+func TestProjectOnPath(t *testing.T) {
+	path := [][]float64{{77, 28}, {77, 29}, {78, 29}}
+
+	// * A point beside the middle of the first segment projects onto it
+	position, segment, distance := ProjectOnPath(path, 28.5, 77.01)
+	require.Equal(t, 0, segment)
+	require.InDelta(t, 77, position[0], 1e-9)
+	require.InDelta(t, 28.5, position[1], 1e-9)
+	require.InDelta(t, Haversine(28.5, 77, 28.5, 77.01), distance, 1)
+
+	// * Points past the end project onto the last position
+	position, segment, _ = ProjectOnPath(path, 29, 78.5)
+	require.Equal(t, 1, segment)
+	require.Equal(t, []float64{78, 29}, position)
+
+	position, segment, _ = ProjectOnPath(nil, 28, 77)
+	require.Nil(t, position)
+	require.Equal(t, -1, segment)
+}
+
+func TestDetour(t *testing.T) {
+	path := [][]float64{{77, 28}, {77, 28.5}, {77, 29}}
+
+	require.InDelta(t, 0, Detour(path, 28.25, 77), 1e-6)
+
+	near := Detour(path, 28.25, 77.01)
+	far := Detour(path, 28.25, 77.05)
+	require.Greater(t, near, 0.0)
+	require.Greater(t, far, near)
+
+	require.InDelta(t, 2*Haversine(28, 77, 28, 77.01), Detour([][]float64{{77, 28}}, 28, 77.01), 1e-6)
+}
+
+func TestDistanceAlong(t *testing.T) {
+	path := [][]float64{{77, 28}, {77, 29}, {78, 29}}
+
+	first, firstSegment, _ := ProjectOnPath(path, 28.5, 77.01)
+	second, secondSegment, _ := ProjectOnPath(path, 29.01, 77.5)
+
+	require.InDelta(t, Haversine(28, 77, 28.5, 77), DistanceAlong(path, firstSegment, first), 1)
+	require.InDelta(t, PathLength(path[:2])+Haversine(29, 77, 29, 77.5), DistanceAlong(path, secondSegment, second), 1)
+	require.Less(t, DistanceAlong(path, firstSegment, first), DistanceAlong(path, secondSegment, second))
+
+	require.Zero(t, DistanceAlong(path, -1, nil))
+}
@@ -10,20 +10,31 @@ type Collection struct {
 	Ride         *mongo.Collection
 	Request      *mongo.Collection
 	Notification *mongo.Collection
+	Session      *mongo.Collection
+	RevokedToken *mongo.Collection
+	// * One time codes sent to verify phone numbers
+	PhoneVerification *mongo.Collection
+	// * Push notifications that could not be delivered
+	NotificationDeadLetter *mongo.Collection
+	RideSummary            *mongo.Collection
+	RecurringRide          *mongo.Collection
+	// * Maps api responses, only used with the mongo maps cache store
+	MapsCache *mongo.Collection
 }
 
 func NewCollection(client *mongo.Client, config Config) Collection {
 	return Collection{
-		Driver:       client.Database(config.DBName).Collection("drivers"),
-		Passenger:    client.Database(config.DBName).Collection("passengers"),
-		Ride:         client.Database(config.DBName).Collection("rides"),
-		Request:      client.Database(config.DBName).Collection("requests"),
-		Notification: client.Database(config.DBName).Collection("notifications"),
+		Driver:                 client.Database(config.DBName).Collection("drivers"),
+		Passenger:              client.Database(config.DBName).Collection("passengers"),
+		Ride:                   client.Database(config.DBName).Collection("rides"),
+		Request:                client.Database(config.DBName).Collection("requests"),
+		Notification:           client.Database(config.DBName).Collection("notifications"),
+		Session:                client.Database(config.DBName).Collection("sessions"),
+		RevokedToken:           client.Database(config.DBName).Collection("revoked_tokens"),
+		PhoneVerification:      client.Database(config.DBName).Collection("phone_verifications"),
+		NotificationDeadLetter: client.Database(config.DBName).Collection("notification_dead_letters"),
+		RideSummary:            client.Database(config.DBName).Collection("ride_summaries"),
+		RecurringRide:          client.Database(config.DBName).Collection("recurring_rides"),
+		MapsCache:              client.Database(config.DBName).Collection("maps_cache"),
 	}
 }
-
-
-
-
-
-